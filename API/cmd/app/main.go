@@ -2,8 +2,11 @@ package main
 
 import (
 	"api/internal/app"
+	"api/internal/tracing"
 	"api/pkg/config"
 	"api/pkg/logger"
+	"api/pkg/logger/sl"
+	"context"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -17,10 +20,15 @@ func main() {
 
 	log.Info("starting application", slog.Any("config", config))
 
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+
 	appplication := app.New(log, config)
 
 	go func() {
-		appplication.Run()
+		appplication.MustRun()
 	}()
 
 	stop := make(chan os.Signal, 1)
@@ -28,5 +36,18 @@ func main() {
 
 	<-stop
 
+	log.Info("stopping application", slog.Duration("timeout", config.Api.ShutdownTimeout))
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Api.ShutdownTimeout)
+	defer cancel()
+
+	if err := appplication.Shutdown(ctx); err != nil {
+		log.Error("application is stopped with error", sl.Err(err))
+	}
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("cannot flush traces", sl.Err(err))
+	}
+
 	log.Info("application is stopped")
 }
@@ -1,46 +1,189 @@
 package app
 
 import (
+	"api/internal/domain/models"
+	healthhandler "api/internal/handler/health"
 	userhandler "api/internal/handler/user"
+	"api/internal/middleware/auth"
+	"api/internal/middleware/metrics"
 	"api/internal/service/userservice"
 	"api/internal/storage/userstorage"
 	"api/pkg/config"
+	"api/pkg/logger/sl"
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	log    *slog.Logger
-	config *config.Config
+	log         *slog.Logger
+	config      *config.Config
+	userStorage *userstorage.GRPCUserServer
+	health      *healthhandler.HealthHandler
+	server      *http.Server
 }
 
-func New(log *slog.Logger, config *config.Config) *App {
-	return &App{
-		log:    log,
-		config: config,
+// New wires the API. opts are passed on to the connection to UsersService.
+func New(log *slog.Logger, config *config.Config, opts ...grpc.DialOption) *App {
+	userStorage, err := userstorage.New(log, config, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	a := &App{
+		log:         log,
+		config:      config,
+		userStorage: userStorage,
+		health: healthhandler.New(log, config.Api.Readiness, healthhandler.Dependency{
+			Name:  "users_service",
+			Check: userStorage.Health,
+		}),
+	}
+
+	a.server = &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Api.Port),
+		Handler:           otelhttp.NewHandler(a.routes(), "api"),
+		ReadTimeout:       config.Api.Timeout,
+		ReadHeaderTimeout: config.Api.ReadHeaderTimeout,
+		WriteTimeout:      config.Api.Timeout,
+		IdleTimeout:       config.Api.IdleTimeout,
+	}
+
+	return a
+}
+
+// Handler returns the handler served by Run, for tests that serve it
+// themselves.
+func (a *App) Handler() http.Handler {
+	return a.server.Handler
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+// Run serves HTTP requests until Shutdown is called.
+func (a *App) Run() error {
+	const op = "app.Run"
+	log := a.log.With(
+		"op", op,
+	)
+
+	log.Info("starting HTTP server", slog.String("addr", a.server.Addr))
+
+	if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Shutdown fails readiness probes, keeps serving for the drain delay so load
+// balancers notice, then stops accepting connections and waits for
+// in-flight requests until ctx is done. The connection to UsersService is
+// closed afterwards, as draining requests still use it.
+func (a *App) Shutdown(ctx context.Context) error {
+	const op = "app.Shutdown"
+	log := a.log.With(
+		"op", op,
+	)
+
+	a.health.Shutdown()
+
+	log.Info("waiting for load balancers to drain", slog.Duration("delay", a.config.Api.Readiness.DrainDelay))
+	select {
+	case <-time.After(a.config.Api.Readiness.DrainDelay):
+	case <-ctx.Done():
 	}
+
+	var errs []error
+	if err := a.server.Shutdown(ctx); err != nil {
+		log.Error("cannot drain in-flight requests", sl.Err(err))
+		errs = append(errs, err)
+
+		// Cut off the requests left, so they don't outlive the gRPC connection.
+		if err := a.server.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := a.userStorage.Close(); err != nil {
+		log.Error("cannot close gRPC connection", sl.Err(err))
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
-func (a *App) Run() {
-	userStorage := userstorage.New(a.log, a.config.ServerHost, a.config.ServerPort)
-	userService := userservice.New(a.log, userStorage)
+func (a *App) routes() http.Handler {
+	userService := userservice.New(a.log, a.userStorage)
 	userHandler := userhandler.New(a.log, userService)
 
+	authenticator, err := auth.New(a.log, userService, a.config.Auth)
+	if err != nil {
+		panic(err)
+	}
+
+	authenticated := authenticator.Require(auth.Authenticated())
+	selfOrAdmin := authenticator.Require(auth.SelfOrAdmin("id"))
+	admin := authenticator.Require(auth.HasRole(models.RoleAdmin))
+
 	r := mux.NewRouter()
-	r.HandleFunc("/api/v1/health-check", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
+	r.Use(routeSpan)
+	r.Use(metrics.Middleware)
+	r.Use(authenticator.Middleware)
+
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
+
+	r.HandleFunc("/livez", a.health.LivezHandler).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", a.health.ReadyzHandler).Methods(http.MethodGet)
 
-	r.HandleFunc("/api/v1/users", userHandler.GetUsersHandler).Methods(http.MethodGet)
-	r.HandleFunc("/api/v1/users/{id}", userHandler.GetUserByIdHandler).Methods(http.MethodGet)
+	r.Handle("/api/v1/users", authenticated(http.HandlerFunc(userHandler.GetUsersHandler))).Methods(http.MethodGet)
+	// Registered before /api/v1/users/{id}, which would match it otherwise.
+	r.Handle("/api/v1/users/export", admin(http.HandlerFunc(userHandler.ExportUsersHandler))).Methods(http.MethodGet)
+	r.Handle("/api/v1/users/{id}", authenticated(http.HandlerFunc(userHandler.GetUserByIdHandler))).Methods(http.MethodGet)
 	r.HandleFunc("/api/v1/users", userHandler.InsertUserHandler).Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/users/{id}", userHandler.UpdateUserHandler).Methods(http.MethodPut)
-	r.HandleFunc("/api/v1/users/{id}", userHandler.DeleteUserHandler).Methods(http.MethodDelete)
+	r.Handle("/api/v1/users/{id}", selfOrAdmin(http.HandlerFunc(userHandler.UpdateUserHandler))).Methods(http.MethodPut)
+	r.Handle("/api/v1/users/{id}", selfOrAdmin(http.HandlerFunc(userHandler.DeleteUserHandler))).Methods(http.MethodDelete)
 
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", a.config.Api.Port), r); err != nil {
-		panic(err)
-	}
+	r.HandleFunc("/api/v1/auth/login", userHandler.LoginHandler).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/.well-known/jwks.json", userHandler.JWKSHandler).Methods(http.MethodGet)
+
+	r.HandleFunc("/api/v1/auth/sessions/refresh", userHandler.RefreshSessionHandler).Methods(http.MethodPost)
+	r.Handle("/api/v1/auth/sessions", authenticated(http.HandlerFunc(userHandler.ListSessionsHandler))).Methods(http.MethodGet)
+	r.Handle("/api/v1/auth/sessions", authenticated(http.HandlerFunc(userHandler.RevokeSessionsHandler))).Methods(http.MethodDelete)
+	r.Handle("/api/v1/auth/sessions/{id}", authenticated(http.HandlerFunc(userHandler.RevokeSessionHandler))).Methods(http.MethodDelete)
+
+	return r
+}
+
+// routeSpan names the server span opened by otelhttp after the matched route
+// template, so spans of one endpoint are grouped whatever the path values.
+func routeSpan(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + tpl)
+				span.SetAttributes(attribute.String("http.route", tpl))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
@@ -8,9 +8,16 @@ import (
 )
 
 type IUserService interface {
-	GetUsers(context.Context) ([]models.User, error)
+	GetUsers(context.Context, models.UsersQuery) (models.UsersPage, error)
+	StreamUsers(ctx context.Context, query models.UsersQuery, fn func(models.User) error) error
 	GetUserById(context.Context, uuid.UUID) (models.User, error)
 	UpdateUser(context.Context, uuid.UUID, models.User) (models.User, error)
 	InsertUser(context.Context, models.User) (models.User, error)
 	DeleteUser(context.Context, uuid.UUID) (models.User, error)
+	Authenticate(ctx context.Context, login, password string, client models.ClientInfo) (models.User, models.Tokens, error)
+	GetJWKS(context.Context) ([]models.JWK, error)
+	RefreshSession(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error)
+	ListSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) (models.Session, error)
+	RevokeSessions(ctx context.Context, userId uuid.UUID) (int64, error)
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+const RoleAdmin = "admin"
+
+// Principal is the authenticated caller of a request: either a user
+// holding an access token or a service using an API key.
+type Principal struct {
+	UserId uuid.UUID
+	APIKey string
+	Roles  []string
+}
+
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
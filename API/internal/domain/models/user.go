@@ -1,9 +1,44 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type User struct {
-	Id       uuid.UUID `json:"id,omitempty"`
-	Login    string    `json:"login,omitempty"`
-	Password string    `json:"password,omitempty"`
+	Id        uuid.UUID  `json:"id,omitempty"`
+	Login     string     `json:"login,omitempty"`
+	Password  string     `json:"password,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type UserSort int
+
+const (
+	SortCreatedAtAsc UserSort = iota
+	SortCreatedAtDesc
+	SortLoginAsc
+	SortLoginDesc
+)
+
+// UsersQuery is a request of a page of users. Zero values disable the
+// corresponding filter; CreatedAfter is inclusive, CreatedBefore is exclusive.
+type UsersQuery struct {
+	PageSize      int
+	PageToken     string
+	LoginPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          UserSort
+}
+
+type UsersPage struct {
+	Users         []User `json:"users"`
+	NextPageToken string `json:"next_page_token,omitempty"`
 }
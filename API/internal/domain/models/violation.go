@@ -0,0 +1,8 @@
+package models
+
+// FieldViolation is a field of a request that UsersService rejected, and
+// why.
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,23 @@
+package profiles
+
+import (
+	"api/internal/domain/models"
+	"api/proto/gen"
+
+	"github.com/google/uuid"
+)
+
+func ProtoSessionToSession(session *umv1.Session) models.Session {
+	id, _ := uuid.Parse(session.GetId())
+	userId, _ := uuid.Parse(session.GetUserId())
+
+	return models.Session{
+		Id:         id,
+		UserId:     userId,
+		UserAgent:  session.GetUserAgent(),
+		IP:         session.GetIp(),
+		CreatedAt:  session.GetCreatedAt().AsTime(),
+		LastUsedAt: session.GetLastUsedAt().AsTime(),
+		ExpiresAt:  session.GetExpiresAt().AsTime(),
+	}
+}
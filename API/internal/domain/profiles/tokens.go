@@ -0,0 +1,28 @@
+package profiles
+
+import (
+	"api/internal/domain/models"
+	"api/proto/gen"
+)
+
+func ProtoTokensToTokens(tokens *umv1.Tokens) models.Tokens {
+	return models.Tokens{
+		AccessToken:  tokens.GetAccessToken(),
+		TokenType:    tokens.GetTokenType(),
+		ExpiresIn:    tokens.GetExpiresIn(),
+		RefreshToken: tokens.GetRefreshToken(),
+	}
+}
+
+func ProtoJWKToJWK(jwk *umv1.JWK) models.JWK {
+	return models.JWK{
+		Kty: jwk.GetKty(),
+		Kid: jwk.GetKid(),
+		Alg: jwk.GetAlg(),
+		Use: jwk.GetUse(),
+		Crv: jwk.GetCrv(),
+		X:   jwk.GetX(),
+		N:   jwk.GetN(),
+		E:   jwk.GetE(),
+	}
+}
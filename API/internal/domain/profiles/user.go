@@ -5,6 +5,7 @@ import (
 	"api/proto/gen"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func UserToProtoUser(user models.User) *umv1.User {
@@ -18,9 +19,58 @@ func UserToProtoUser(user models.User) *umv1.User {
 func ProtoUserToUser(user *umv1.User) models.User {
 	id, _ := uuid.Parse(user.Id)
 
-	return models.User{
-		Id:       id,
-		Login:    user.Login,
-		Password: user.Password,
+	res := models.User{
+		Id:    id,
+		Login: user.Login,
+	}
+
+	if user.CreatedAt != nil {
+		createdAt := user.CreatedAt.AsTime()
+		res.CreatedAt = &createdAt
+	}
+
+	return res
+}
+
+func UsersQueryToProtoGetUsersRequest(query models.UsersQuery) *umv1.GetUsersRequest {
+	req := &umv1.GetUsersRequest{
+		PageSize:    int32(query.PageSize),
+		PageToken:   query.PageToken,
+		LoginPrefix: query.LoginPrefix,
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		req.CreatedAfter = timestamppb.New(query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		req.CreatedBefore = timestamppb.New(query.CreatedBefore)
 	}
+
+	switch query.Sort {
+	case models.SortCreatedAtDesc:
+		req.Sort = umv1.UserSortOrder_USER_SORT_ORDER_CREATED_AT_DESC
+	case models.SortLoginAsc:
+		req.Sort = umv1.UserSortOrder_USER_SORT_ORDER_LOGIN_ASC
+	case models.SortLoginDesc:
+		req.Sort = umv1.UserSortOrder_USER_SORT_ORDER_LOGIN_DESC
+	default:
+		req.Sort = umv1.UserSortOrder_USER_SORT_ORDER_CREATED_AT_ASC
+	}
+
+	return req
+}
+
+func UsersQueryToProtoStreamUsersRequest(query models.UsersQuery) *umv1.StreamUsersRequest {
+	req := &umv1.StreamUsersRequest{
+		LoginPrefix: query.LoginPrefix,
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		req.CreatedAfter = timestamppb.New(query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		req.CreatedBefore = timestamppb.New(query.CreatedBefore)
+	}
+
+	return req
 }
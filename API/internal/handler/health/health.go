@@ -0,0 +1,159 @@
+package healthhandler
+
+import (
+	"api/pkg/config"
+	"api/pkg/logger/sl"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+	statusShutdown    = "shutting_down"
+)
+
+// Dependency is a service the API can't serve requests without.
+type Dependency struct {
+	Name  string
+	Check func(context.Context) error
+}
+
+type dependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Status       string             `json:"status"`
+	Dependencies []dependencyStatus `json:"dependencies"`
+}
+
+// HealthHandler serves the liveness and readiness probes. Results of the
+// dependency checks are cached for ReadinessConfig.CacheTTL, so frequent
+// probes don't load the dependencies.
+type HealthHandler struct {
+	log          *slog.Logger
+	cfg          config.ReadinessConfig
+	dependencies []Dependency
+	shuttingDown atomic.Bool
+
+	mu        sync.Mutex
+	cached    readiness
+	checkedAt time.Time
+}
+
+func New(log *slog.Logger, cfg config.ReadinessConfig, dependencies ...Dependency) *HealthHandler {
+	return &HealthHandler{
+		log:          log,
+		cfg:          cfg,
+		dependencies: dependencies,
+	}
+}
+
+// Shutdown makes readiness fail from now on, so load balancers stop
+// sending requests while the in-flight ones are drained.
+func (h *HealthHandler) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// LivezHandler reports that the process is up. It doesn't check any
+// dependency: restarting the API wouldn't bring them back.
+func (h *HealthHandler) LivezHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// ReadyzHandler reports whether the API can serve requests, along with the
+// status and latency of every dependency.
+func (h *HealthHandler) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, readiness{
+			Status:       statusShutdown,
+			Dependencies: []dependencyStatus{},
+		})
+		return
+	}
+
+	res := h.check(r.Context())
+	if res.Status != statusOK {
+		writeJSON(w, http.StatusServiceUnavailable, res)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// check returns the cached result while it is fresh. Probes arriving while
+// the dependencies are checked wait for that check instead of starting one.
+func (h *HealthHandler) check(ctx context.Context) readiness {
+	const op = "handler.health.check"
+	log := h.log.With(
+		"op", op,
+	)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checkedAt.IsZero() && time.Since(h.checkedAt) < h.cfg.CacheTTL {
+		return h.cached
+	}
+
+	res := readiness{
+		Status:       statusOK,
+		Dependencies: make([]dependencyStatus, len(h.dependencies)),
+	}
+
+	var wg sync.WaitGroup
+	for i, dep := range h.dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Not bound to the probe's context: the result is shared
+			// with other probes through the cache.
+			checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.cfg.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := dep.Check(checkCtx)
+
+			status := dependencyStatus{
+				Name:      dep.Name,
+				Status:    statusOK,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				log.WarnContext(ctx, "dependency is not ready", slog.String("dependency", dep.Name), sl.Err(err))
+				status.Status = statusUnavailable
+				status.Error = err.Error()
+			}
+			res.Dependencies[i] = status
+		}()
+	}
+	wg.Wait()
+
+	for _, dep := range res.Dependencies {
+		if dep.Status != statusOK {
+			res.Status = statusUnavailable
+		}
+	}
+
+	h.cached = res
+	h.checkedAt = time.Now()
+
+	return res
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
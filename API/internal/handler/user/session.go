@@ -0,0 +1,203 @@
+package userhandler
+
+import (
+	"api/internal/domain/models"
+	"api/internal/middleware/auth"
+	serviceerror "api/internal/service"
+	"api/pkg/logger/sl"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+func (u *UserHandler) RefreshSessionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.RefreshSessionHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.ErrorContext(r.Context(), "cannot read and parse request body", sl.Err(err))
+		http.Error(w, "cannot read and parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if body.RefreshToken == "" {
+		log.ErrorContext(r.Context(), "refresh token is required", sl.Err(fmt.Errorf("refresh token is required")))
+		http.Error(w, "refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := u.service.RefreshSession(r.Context(), body.RefreshToken, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidCredentials) {
+			log.WarnContext(r.Context(), "invalid refresh token", sl.Err(err))
+			http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot refresh session", sl.Err(err))
+		http.Error(w, "cannot refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	WriteUsersToBody(w, http.StatusOK, tokens)
+}
+
+func (u *UserHandler) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.ListSessionsHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	userId, status, err := sessionsOwner(r)
+	if err != nil {
+		log.WarnContext(r.Context(), "cannot resolve sessions owner", sl.Err(err))
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	sessions, err := u.service.ListSessions(r.Context(), userId)
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot fetch sessions", sl.Err(err))
+		http.Error(w, "cannot fetch sessions", http.StatusInternalServerError)
+		return
+	}
+
+	WriteUsersToBody(w, http.StatusOK, sessions)
+}
+
+func (u *UserHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.RevokeSessionHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	userId, status, err := sessionsOwner(r)
+	if err != nil {
+		log.WarnContext(r.Context(), "cannot resolve sessions owner", sl.Err(err))
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	sessionId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		log.ErrorContext(r.Context(), "id must be uuid", sl.Err(err))
+		http.Error(w, "id must be uuid", http.StatusBadRequest)
+		return
+	}
+
+	session, err := u.service.RevokeSession(r.Context(), userId, sessionId)
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrNotFound) {
+			log.WarnContext(r.Context(), "session not found", sl.Err(err))
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot revoke session", sl.Err(err))
+		http.Error(w, "cannot revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	WriteUsersToBody(w, http.StatusOK, session)
+}
+
+func (u *UserHandler) RevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.RevokeSessionsHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	userId, status, err := sessionsOwner(r)
+	if err != nil {
+		log.WarnContext(r.Context(), "cannot resolve sessions owner", sl.Err(err))
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	revoked, err := u.service.RevokeSessions(r.Context(), userId)
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot revoke sessions", sl.Err(err))
+		http.Error(w, "cannot revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	WriteUsersToBody(w, http.StatusOK, struct {
+		Revoked int64 `json:"revoked"`
+	}{
+		Revoked: revoked,
+	})
+}
+
+// sessionsOwner returns the user whose sessions the request manages: the
+// caller itself, or the user from the user_id query parameter for admins.
+func sessionsOwner(r *http.Request) (uuid.UUID, int, error) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, http.StatusUnauthorized, errors.New("authentication required")
+	}
+
+	query := r.URL.Query().Get("user_id")
+	if query == "" {
+		if principal.UserId == uuid.Nil {
+			return uuid.Nil, http.StatusBadRequest, errors.New("user_id is required")
+		}
+		return principal.UserId, http.StatusOK, nil
+	}
+
+	userId, err := uuid.Parse(query)
+	if err != nil {
+		return uuid.Nil, http.StatusBadRequest, errors.New("user_id must be uuid")
+	}
+
+	if userId != principal.UserId && !principal.HasRole(models.RoleAdmin) {
+		return uuid.Nil, http.StatusForbidden, errors.New("access denied")
+	}
+
+	return userId, http.StatusOK, nil
+}
+
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return models.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+	}
+}
@@ -9,12 +9,27 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// exportFlushEvery is how many users are written between flushes of an export.
+const exportFlushEvery = 100
+
+// exportWriteTimeout is how long writing the next batch of an export may
+// take. The write deadline is pushed back after every flush, so exports
+// aren't cut off by the server's write timeout.
+const exportWriteTimeout = 30 * time.Second
+
+// defaultRetryAfter is suggested to clients when UsersService is unavailable
+// but the circuit breaker hasn't opened yet.
+const defaultRetryAfter = time.Second
+
 type UserHandler struct {
 	log     *slog.Logger
 	service service.IUserService
@@ -27,26 +42,128 @@ func New(log *slog.Logger, service service.IUserService) *UserHandler {
 	}
 }
 
+// GetUsersHandler returns a page of users. Query parameters: page_size,
+// page_token, login_prefix, created_after and created_before (RFC 3339) and
+// sort (created_at, -created_at, login or -login).
 func (u *UserHandler) GetUsersHandler(w http.ResponseWriter, r *http.Request) {
 	const op = "handler.user.GetUsersHandler"
 	log := u.log.With(
 		"op", op,
 	)
 
-	users, err := u.service.GetUsers(r.Context())
+	query, err := usersQuery(r)
 	if err != nil {
+		log.WarnContext(r.Context(), "invalid query parameters", sl.Err(err))
+		writeInvalidArgument(w, err)
+		return
+	}
+
+	page, err := u.service.GetUsers(r.Context(), query)
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid users query", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("users not found", sl.Err(err))
-			WriteUsersToBody(w, http.StatusNotFound, []models.User{})
+			log.WarnContext(r.Context(), "users not found", sl.Err(err))
+			WriteUsersToBody(w, http.StatusNotFound, models.UsersPage{Users: []models.User{}})
 			return
 		}
 
-		log.Error("cannot fetch users", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot fetch users", sl.Err(err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	WriteUsersToBody(w, http.StatusOK, users)
+	WriteUsersToBody(w, http.StatusOK, page)
+}
+
+// ExportUsersHandler streams all users matching login_prefix, created_after
+// and created_before as newline delimited JSON. Once the first user is
+// written the status can't change anymore, so a failure midway just cuts
+// the stream short.
+func (u *UserHandler) ExportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.ExportUsersHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	query, err := usersQuery(r)
+	if err != nil {
+		log.WarnContext(r.Context(), "invalid query parameters", sl.Err(err))
+		writeInvalidArgument(w, err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	controller := http.NewResponseController(w)
+	extendDeadline := func() {
+		if err := controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+			log.WarnContext(r.Context(), "cannot extend write deadline", sl.Err(err))
+		}
+	}
+	extendDeadline()
+
+	var written int
+	err = u.service.StreamUsers(r.Context(), query, func(user models.User) error {
+		if written == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err := enc.Encode(user); err != nil {
+			return err
+		}
+		written++
+
+		if written%exportFlushEvery == 0 {
+			if flusher != nil {
+				flusher.Flush()
+			}
+			extendDeadline()
+		}
+
+		return nil
+	})
+	if err != nil {
+		if written > 0 {
+			log.ErrorContext(r.Context(), "export interrupted", slog.Int("written", written), sl.Err(err))
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid users query", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot export users", sl.Err(err))
+		http.Error(w, "cannot export users", http.StatusInternalServerError)
+		return
+	}
+
+	if written == 0 {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	log.InfoContext(r.Context(), "users exported", slog.Int("written", written))
 }
 
 func (u *UserHandler) GetUserByIdHandler(w http.ResponseWriter, r *http.Request) {
@@ -57,14 +174,14 @@ func (u *UserHandler) GetUserByIdHandler(w http.ResponseWriter, r *http.Request)
 
 	id, ok := mux.Vars(r)["id"]
 	if !ok {
-		log.Error("id is required", sl.Err(fmt.Errorf("id is required")))
+		log.ErrorContext(r.Context(), "id is required", sl.Err(fmt.Errorf("id is required")))
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
 
 	uuidId, err := uuid.Parse(id)
 	if err != nil {
-		log.Error("id must be uuid", sl.Err(err))
+		log.ErrorContext(r.Context(), "id must be uuid", sl.Err(err))
 		http.Error(w, "id must be uuid", http.StatusBadRequest)
 		return
 	}
@@ -72,12 +189,24 @@ func (u *UserHandler) GetUserByIdHandler(w http.ResponseWriter, r *http.Request)
 	user, err := u.service.GetUserById(r.Context(), uuidId)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(r.Context(), "user not found", sl.Err(err))
 			http.Error(w, "user not found", http.StatusNotFound)
 			return
 		}
 
-		log.Error("cannot get user by id", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid request", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot get user by id", sl.Err(err))
 		http.Error(w, "cannot get user by id", http.StatusInternalServerError)
 		return
 	}
@@ -93,20 +222,38 @@ func (u *UserHandler) InsertUserHandler(w http.ResponseWriter, r *http.Request)
 
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		log.Error("cannot read and parse request body", sl.Err(err))
+		log.ErrorContext(r.Context(), "cannot read and parse request body", sl.Err(err))
 		http.Error(w, "cannot read and parse request body", http.StatusBadRequest)
 		return
 	}
 
 	user, err := u.service.InsertUser(r.Context(), user)
 	if err != nil {
+		if errors.Is(err, serviceerror.ErrLoginTaken) {
+			log.WarnContext(r.Context(), "login is taken", sl.Err(err))
+			http.Error(w, "login is taken", http.StatusConflict)
+			return
+		}
+
 		if errors.Is(err, serviceerror.ErrAlreadyExists) {
-			log.Warn("user already exists", sl.Err(err))
+			log.WarnContext(r.Context(), "user already exists", sl.Err(err))
 			http.Error(w, "user already exists", http.StatusConflict)
 			return
 		}
 
-		log.Error("cannot insert user", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid request", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot insert user", sl.Err(err))
 		http.Error(w, "cannot insert user", http.StatusInternalServerError)
 		return
 	}
@@ -122,21 +269,21 @@ func (u *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 
 	id, ok := mux.Vars(r)["id"]
 	if !ok {
-		log.Error("id is required", sl.Err(fmt.Errorf("id is required")))
+		log.ErrorContext(r.Context(), "id is required", sl.Err(fmt.Errorf("id is required")))
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
 
 	uuidId, err := uuid.Parse(id)
 	if err != nil {
-		log.Error("id must be uuid", sl.Err(err))
+		log.ErrorContext(r.Context(), "id must be uuid", sl.Err(err))
 		http.Error(w, "id must be uuid", http.StatusBadRequest)
 		return
 	}
 
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		log.Error("cannot read and parse request body", sl.Err(err))
+		log.ErrorContext(r.Context(), "cannot read and parse request body", sl.Err(err))
 		http.Error(w, "cannot read and parse request body", http.StatusBadRequest)
 		return
 	}
@@ -144,12 +291,30 @@ func (u *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http.Request)
 	user, err = u.service.UpdateUser(r.Context(), uuidId, user)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(r.Context(), "user not found", sl.Err(err))
 			http.Error(w, "user not found", http.StatusNotFound)
 			return
 		}
 
-		log.Error("cannot update user", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrLoginTaken) {
+			log.WarnContext(r.Context(), "login is taken", sl.Err(err))
+			http.Error(w, "login is taken", http.StatusConflict)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid request", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot update user", sl.Err(err))
 		http.Error(w, "cannot update user", http.StatusInternalServerError)
 		return
 	}
@@ -165,14 +330,14 @@ func (u *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 
 	id, ok := mux.Vars(r)["id"]
 	if !ok {
-		log.Error("id is required", sl.Err(fmt.Errorf("id is required")))
+		log.ErrorContext(r.Context(), "id is required", sl.Err(fmt.Errorf("id is required")))
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
 
 	uuidId, err := uuid.Parse(id)
 	if err != nil {
-		log.Error("id must be uuid", sl.Err(err))
+		log.ErrorContext(r.Context(), "id must be uuid", sl.Err(err))
 		http.Error(w, "id must be uuid", http.StatusBadRequest)
 		return
 	}
@@ -180,12 +345,24 @@ func (u *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 	user, err := u.service.DeleteUser(r.Context(), uuidId)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(r.Context(), "user not found", sl.Err(err))
 			http.Error(w, "user not found", http.StatusNotFound)
 			return
 		}
 
-		log.Error("cannot delete user", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrInvalidArgument) {
+			log.WarnContext(r.Context(), "invalid request", sl.Err(err))
+			writeInvalidArgument(w, err)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot delete user", sl.Err(err))
 		http.Error(w, "cannot delete user", http.StatusInternalServerError)
 		return
 	}
@@ -193,6 +370,114 @@ func (u *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request)
 	WriteUsersToBody(w, http.StatusOK, user)
 }
 
+func (u *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.LoginHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	var credentials models.Credentials
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		log.ErrorContext(r.Context(), "cannot read and parse request body", sl.Err(err))
+		http.Error(w, "cannot read and parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if credentials.Login == "" || credentials.Password == "" {
+		log.ErrorContext(r.Context(), "login and password are required", sl.Err(fmt.Errorf("login and password are required")))
+		http.Error(w, "login and password are required", http.StatusBadRequest)
+		return
+	}
+
+	user, tokens, err := u.service.Authenticate(r.Context(), credentials.Login, credentials.Password, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidCredentials) {
+			log.WarnContext(r.Context(), "invalid credentials", sl.Err(err))
+			http.Error(w, "invalid login or password", http.StatusUnauthorized)
+			return
+		}
+
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot authenticate user", sl.Err(err))
+		http.Error(w, "cannot authenticate user", http.StatusInternalServerError)
+		return
+	}
+
+	WriteUsersToBody(w, http.StatusOK, struct {
+		User models.User `json:"user"`
+		models.Tokens
+	}{
+		User:   user,
+		Tokens: tokens,
+	})
+}
+
+func (u *UserHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handler.user.JWKSHandler"
+	log := u.log.With(
+		"op", op,
+	)
+
+	jwks, err := u.service.GetJWKS(r.Context())
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrUnavailable) {
+			log.WarnContext(r.Context(), "users service unavailable", sl.Err(err))
+			writeUnavailable(w, err)
+			return
+		}
+
+		log.ErrorContext(r.Context(), "cannot fetch jwks", sl.Err(err))
+		http.Error(w, "cannot fetch jwks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	WriteUsersToBody(w, http.StatusOK, struct {
+		Keys []models.JWK `json:"keys"`
+	}{
+		Keys: jwks,
+	})
+}
+
+// writeUnavailable responds with 503 and a Retry-After taken from the error
+// when it knows when UsersService may be back.
+func writeUnavailable(w http.ResponseWriter, err error) {
+	retryAfter := defaultRetryAfter
+
+	var hint interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hint) {
+		retryAfter = hint.RetryAfter()
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, "users service unavailable", http.StatusServiceUnavailable)
+}
+
+// writeInvalidArgument responds with 422 and a JSON body listing the fields
+// UsersService rejected, or with a bare 400 when it didn't name any.
+func writeInvalidArgument(w http.ResponseWriter, err error) {
+	var invalid *serviceerror.InvalidArgumentError
+	if !errors.As(err, &invalid) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(struct {
+		Error  string                  `json:"error"`
+		Fields []models.FieldViolation `json:"fields"`
+	}{
+		Error:  "invalid request",
+		Fields: invalid.Violations,
+	})
+}
+
 func WriteUsersToBody(w http.ResponseWriter, status int, users any) {
 	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
@@ -200,3 +485,64 @@ func WriteUsersToBody(w http.ResponseWriter, status int, users any) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+var userSorts = map[string]models.UserSort{
+	"created_at":  models.SortCreatedAtAsc,
+	"-created_at": models.SortCreatedAtDesc,
+	"login":       models.SortLoginAsc,
+	"-login":      models.SortLoginDesc,
+}
+
+// usersQuery parses the query parameters of the user listings. Malformed
+// ones are reported as an *serviceerror.InvalidArgumentError, like the
+// fields UsersService rejects.
+func usersQuery(r *http.Request) (models.UsersQuery, error) {
+	params := r.URL.Query()
+	query := models.UsersQuery{
+		PageToken:   params.Get("page_token"),
+		LoginPrefix: params.Get("login_prefix"),
+	}
+
+	var violations []models.FieldViolation
+	invalid := func(field, description string) {
+		violations = append(violations, models.FieldViolation{Field: field, Description: description})
+	}
+
+	if v := params.Get("page_size"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize < 0 {
+			invalid("page_size", "must be a non-negative integer")
+		}
+		query.PageSize = pageSize
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+	} {
+		if v := params.Get(param.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				invalid(param.name, "must be an RFC 3339 time")
+			}
+			*param.dst = t
+		}
+	}
+
+	if v := params.Get("sort"); v != "" {
+		sort, ok := userSorts[v]
+		if !ok {
+			invalid("sort", "must be one of created_at, -created_at, login, -login")
+		}
+		query.Sort = sort
+	}
+
+	if len(violations) > 0 {
+		return models.UsersQuery{}, &serviceerror.InvalidArgumentError{Violations: violations}
+	}
+
+	return query, nil
+}
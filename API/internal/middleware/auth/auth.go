@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"api/internal/domain/models"
+	"api/pkg/config"
+	"api/pkg/logger/sl"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+var (
+	ErrInvalidToken  = errors.New("invalid access token")
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// Claims are the access token claims issued by UsersService.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.Principal)
+	return principal, ok
+}
+
+type apiKey struct {
+	name  string
+	hash  []byte
+	roles []string
+}
+
+type Authenticator struct {
+	log     *slog.Logger
+	keys    *keySet
+	parser  *jwt.Parser
+	apiKeys []apiKey
+}
+
+func New(log *slog.Logger, provider JWKSProvider, cfg config.AuthConfig) (*Authenticator, error) {
+	const op = "auth.New"
+
+	apiKeys := make([]apiKey, 0, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%s: api key %q: hash must be hex encoded sha256", op, key.Name)
+		}
+
+		apiKeys = append(apiKeys, apiKey{
+			name:  key.Name,
+			hash:  hash,
+			roles: key.Roles,
+		})
+	}
+
+	return &Authenticator{
+		log:  log,
+		keys: newKeySet(provider, cfg.JWKSRefreshInterval),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+		apiKeys: apiKeys,
+	}, nil
+}
+
+// Middleware resolves the principal from a bearer token or an API key and
+// puts it into the request context. Requests without credentials pass through
+// anonymously, it's up to Require to reject them; requests with invalid
+// credentials are rejected right away.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	const op = "middleware.auth.Middleware"
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := a.log.With(
+			"op", op,
+		)
+
+		var (
+			principal models.Principal
+			err       error
+		)
+
+		switch {
+		case r.Header.Get("Authorization") != "":
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				err = ErrInvalidToken
+				break
+			}
+			principal, err = a.verifyToken(r.Context(), token)
+		case r.Header.Get(APIKeyHeader) != "":
+			principal, err = a.verifyAPIKey(r.Header.Get(APIKeyHeader))
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err != nil {
+			log.WarnContext(r.Context(), "cannot authenticate request", sl.Err(err))
+			unauthorized(w)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Check decides whether the principal may perform the request.
+type Check func(r *http.Request, principal models.Principal) bool
+
+// Authenticated allows any authenticated principal.
+func Authenticated() Check {
+	return func(*http.Request, models.Principal) bool {
+		return true
+	}
+}
+
+// HasRole allows principals having the role.
+func HasRole(role string) Check {
+	return func(_ *http.Request, principal models.Principal) bool {
+		return principal.HasRole(role)
+	}
+}
+
+// SelfOrAdmin allows admins and the user whose id is in the route variable.
+func SelfOrAdmin(idVar string) Check {
+	return func(r *http.Request, principal models.Principal) bool {
+		if principal.HasRole(models.RoleAdmin) {
+			return true
+		}
+
+		id, err := uuid.Parse(mux.Vars(r)[idVar])
+		if err != nil {
+			return false
+		}
+
+		return principal.UserId != uuid.Nil && principal.UserId == id
+	}
+}
+
+// Require rejects anonymous requests with 401 and requests the check
+// doesn't allow with 403.
+func (a *Authenticator) Require(check Check) mux.MiddlewareFunc {
+	const op = "middleware.auth.Require"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := a.log.With(
+				"op", op,
+			)
+
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				log.WarnContext(r.Context(), "authentication required", slog.String("path", r.URL.Path))
+				unauthorized(w)
+				return
+			}
+
+			if !check(r, principal) {
+				log.WarnContext(r.Context(), "access denied",
+					slog.String("path", r.URL.Path),
+					slog.String("user_id", principal.UserId.String()),
+					slog.String("api_key", principal.APIKey),
+				)
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (a *Authenticator) verifyToken(ctx context.Context, tokenString string) (models.Principal, error) {
+	var claims Claims
+	_, err := a.parser.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keys.Get(ctx, kid)
+	})
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: subject must be uuid", ErrInvalidToken)
+	}
+
+	return models.Principal{
+		UserId: userId,
+		Roles:  claims.Roles,
+	}, nil
+}
+
+func (a *Authenticator) verifyAPIKey(key string) (models.Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	for _, apiKey := range a.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], apiKey.hash) == 1 {
+			return models.Principal{
+				APIKey: apiKey.name,
+				Roles:  apiKey.roles,
+			}, nil
+		}
+	}
+
+	return models.Principal{}, ErrInvalidAPIKey
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	http.Error(w, "authentication required", http.StatusUnauthorized)
+}
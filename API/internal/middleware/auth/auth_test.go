@@ -0,0 +1,438 @@
+package auth
+
+import (
+	"api/internal/domain/models"
+	"api/pkg/config"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	testIssuer = "users-service"
+	testAPIKey = "secret-api-key"
+)
+
+// testJWKS serves a fixed set of keys and counts how often it is asked.
+type testJWKS struct {
+	keys  []models.JWK
+	err   error
+	calls atomic.Int32
+	// gate, when set, holds every fetch: a fetch sends on it once started and
+	// returns once it receives back.
+	gate chan struct{}
+}
+
+func (p *testJWKS) GetJWKS(context.Context) ([]models.JWK, error) {
+	p.calls.Add(1)
+	if p.gate != nil {
+		p.gate <- struct{}{}
+		<-p.gate
+	}
+	return p.keys, p.err
+}
+
+func ed25519JWK(kid string, key ed25519.PublicKey) models.JWK {
+	return models.JWK{
+		Kty: "OKP",
+		Kid: kid,
+		Alg: jwt.SigningMethodEdDSA.Alg(),
+		Use: "sig",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) models.JWK {
+	return models.JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+type testKeys struct {
+	ed25519 ed25519.PrivateKey
+	rsa     *rsa.PrivateKey
+	jwks    *testJWKS
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeys{
+		ed25519: edKey,
+		rsa:     rsaKey,
+		jwks: &testJWKS{keys: []models.JWK{
+			ed25519JWK("ed", edKey.Public().(ed25519.PublicKey)),
+			rsaJWK("rsa", &rsaKey.PublicKey),
+		}},
+	}
+}
+
+func newTestAuthenticator(t *testing.T, provider JWKSProvider) *Authenticator {
+	t.Helper()
+
+	hash := sha256.Sum256([]byte(testAPIKey))
+	a, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), provider, config.AuthConfig{
+		Issuer:              testIssuer,
+		JWKSRefreshInterval: 5 * time.Minute,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "reporting", Hash: hex.EncodeToString(hash[:]), Roles: []string{"reader"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a
+}
+
+func testClaims(subject uuid.UUID) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			Subject:   subject.String(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		Roles: []string{"user"},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims Claims, key any) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+// serve sends a request through the middleware and returns the status and
+// the principal the next handler saw, if it was called.
+func serve(a *Authenticator, header, value string) (int, *models.Principal) {
+	var principal *models.Principal
+	next := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		p, _ := PrincipalFromContext(r.Context())
+		principal = &p
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+
+	w := httptest.NewRecorder()
+	a.Middleware(next).ServeHTTP(w, r)
+
+	return w.Code, principal
+}
+
+func TestMiddlewareTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	userId := uuid.New()
+
+	expired := testClaims(userId)
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	wrongIssuer := testClaims(userId)
+	wrongIssuer.Issuer = "someone-else"
+
+	noExpiry := testClaims(userId)
+	noExpiry.ExpiresAt = nil
+
+	badSubject := testClaims(userId)
+	badSubject.Subject = "admin"
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The RSA public key used as an HMAC secret: a verifier trusting the alg
+	// header would accept it.
+	rsaPublic := []byte(rsaJWK("rsa", &keys.rsa.PublicKey).N)
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"EdDSA", sign(t, jwt.SigningMethodEdDSA, "ed", testClaims(userId), keys.ed25519), true},
+		{"RS256", sign(t, jwt.SigningMethodRS256, "rsa", testClaims(userId), keys.rsa), true},
+		{"wrong issuer", sign(t, jwt.SigningMethodEdDSA, "ed", wrongIssuer, keys.ed25519), false},
+		{"expired", sign(t, jwt.SigningMethodEdDSA, "ed", expired, keys.ed25519), false},
+		{"without expiry", sign(t, jwt.SigningMethodEdDSA, "ed", noExpiry, keys.ed25519), false},
+		{"subject isn't a uuid", sign(t, jwt.SigningMethodEdDSA, "ed", badSubject, keys.ed25519), false},
+		{"unknown kid", sign(t, jwt.SigningMethodEdDSA, "unknown", testClaims(userId), keys.ed25519), false},
+		{"signed by another key", sign(t, jwt.SigningMethodEdDSA, "ed", testClaims(userId), otherKey), false},
+		{"kid of another key", sign(t, jwt.SigningMethodEdDSA, "rsa", testClaims(userId), keys.ed25519), false},
+		{"alg confusion", sign(t, jwt.SigningMethodHS256, "rsa", testClaims(userId), rsaPublic), false},
+		{"alg none", sign(t, jwt.SigningMethodNone, "ed", testClaims(userId), jwt.UnsafeAllowNoneSignatureType), false},
+		{"garbage", "not.a.token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthenticator(t, keys.jwks)
+
+			code, principal := serve(a, "Authorization", "Bearer "+tt.token)
+			if !tt.valid {
+				if code != http.StatusUnauthorized || principal != nil {
+					t.Errorf("code = %d, want %d", code, http.StatusUnauthorized)
+				}
+				return
+			}
+
+			if principal == nil {
+				t.Fatalf("code = %d, want the request let through", code)
+			}
+			if principal.UserId != userId || !principal.HasRole("user") {
+				t.Errorf("principal = %+v, want user %v with role user", principal, userId)
+			}
+		})
+	}
+}
+
+func TestMiddlewareCredentials(t *testing.T) {
+	a := newTestAuthenticator(t, &testJWKS{})
+
+	tests := []struct {
+		name          string
+		header, value string
+		wantCode      int
+		wantPrincipal *models.Principal
+	}{
+		{"anonymous", "", "", http.StatusOK, &models.Principal{}},
+		{"api key", APIKeyHeader, testAPIKey, http.StatusOK, &models.Principal{APIKey: "reporting", Roles: []string{"reader"}}},
+		{"unknown api key", APIKeyHeader, "guessed-api-key", http.StatusUnauthorized, nil},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, nil},
+		{"bearer without token", "Authorization", "Bearer", http.StatusUnauthorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, principal := serve(a, tt.header, tt.value)
+			if code != tt.wantCode {
+				t.Errorf("code = %d, want %d", code, tt.wantCode)
+			}
+
+			switch {
+			case (principal == nil) != (tt.wantPrincipal == nil):
+				t.Errorf("principal = %+v, want %+v", principal, tt.wantPrincipal)
+			case principal != nil && (principal.APIKey != tt.wantPrincipal.APIKey || !slices.Equal(principal.Roles, tt.wantPrincipal.Roles)):
+				t.Errorf("principal = %+v, want %+v", principal, tt.wantPrincipal)
+			}
+		})
+	}
+}
+
+func TestNewRejectsMalformedAPIKeyHashes(t *testing.T) {
+	for _, hash := range []string{"", "not hex", hex.EncodeToString([]byte("too short"))} {
+		_, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), &testJWKS{}, config.AuthConfig{
+			APIKeys: []config.APIKeyConfig{{Name: "reporting", Hash: hash}},
+		})
+		if err == nil {
+			t.Errorf("New(hash = %q) succeeded", hash)
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	a := newTestAuthenticator(t, &testJWKS{})
+	self := uuid.New()
+
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		id        string
+		wantCode  int
+	}{
+		{"anonymous", nil, self.String(), http.StatusUnauthorized},
+		{"self", &models.Principal{UserId: self}, self.String(), http.StatusOK},
+		{"another user", &models.Principal{UserId: uuid.New()}, self.String(), http.StatusForbidden},
+		{"admin", &models.Principal{UserId: uuid.New(), Roles: []string{models.RoleAdmin}}, self.String(), http.StatusOK},
+		{"api key", &models.Principal{APIKey: "reporting"}, self.String(), http.StatusForbidden},
+		{"nil user", &models.Principal{}, uuid.Nil.String(), http.StatusForbidden},
+		{"id isn't a uuid", &models.Principal{UserId: self}, "me", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Handle("/users/{id}", a.Require(SelfOrAdmin("id"))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+
+			r := httptest.NewRequest(http.MethodGet, "/users/"+tt.id, nil)
+			if tt.principal != nil {
+				r = r.WithContext(WithPrincipal(r.Context(), *tt.principal))
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestKeySetRefetchThrottle(t *testing.T) {
+	keys := newTestKeys(t)
+	clock := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	set := newKeySet(keys.jwks, 5*time.Minute)
+	set.now = func() time.Time { return clock }
+
+	get := func(kid string, wantCalls int32) error {
+		t.Helper()
+
+		_, err := set.Get(context.Background(), kid)
+		if calls := keys.jwks.calls.Load(); calls != wantCalls {
+			t.Fatalf("Get(%q): JWKS fetched %d times, want %d", kid, calls, wantCalls)
+		}
+
+		return err
+	}
+
+	if err := get("ed", 1); err != nil {
+		t.Fatalf("Get(known kid) = %v", err)
+	}
+
+	// Made up key ids don't hit UsersService more than once per interval.
+	for range 10 {
+		if err := get("made-up", 1); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Get(unknown kid) = %v, want ErrUnknownKey", err)
+		}
+	}
+
+	clock = clock.Add(minRefreshInterval)
+	if err := get("made-up", 2); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Get(unknown kid) = %v, want ErrUnknownKey", err)
+	}
+
+	// A key rotated in is picked up by the next refetch allowed.
+	_, rotated, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.jwks.keys = append(keys.jwks.keys, ed25519JWK("rotated", rotated.Public().(ed25519.PublicKey)))
+
+	if err := get("rotated", 2); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Get(rotated kid) within the interval = %v, want ErrUnknownKey", err)
+	}
+	clock = clock.Add(minRefreshInterval)
+	if err := get("rotated", 3); err != nil {
+		t.Fatalf("Get(rotated kid) = %v", err)
+	}
+
+	// Known keys are served from the cache until they expire, and stale
+	// ones are kept while UsersService is unavailable.
+	if err := get("ed", 3); err != nil {
+		t.Fatalf("Get(cached kid) = %v", err)
+	}
+	clock = clock.Add(5 * time.Minute)
+	keys.jwks.err = errors.New("unavailable")
+	if err := get("ed", 4); err != nil {
+		t.Fatalf("Get(stale kid) with UsersService down = %v", err)
+	}
+	// Failed fetches are throttled just like successful ones.
+	for range 10 {
+		if err := get("ed", 4); err != nil {
+			t.Fatalf("Get(stale kid) after a failed fetch = %v", err)
+		}
+		if err := get("made-up", 4); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Get(unknown kid) after a failed fetch = %v, want ErrUnknownKey", err)
+		}
+	}
+	clock = clock.Add(minRefreshInterval)
+	if err := get("made-up", 5); err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Get(unknown kid) with UsersService down = %v, want the fetch error", err)
+	}
+}
+
+func TestKeySetSingleFetchInFlight(t *testing.T) {
+	keys := newTestKeys(t)
+	clock := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	set := newKeySet(keys.jwks, 5*time.Minute)
+	set.now = func() time.Time { return clock }
+	if _, err := set.Get(context.Background(), "ed"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rotated, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.jwks.keys = append(keys.jwks.keys, ed25519JWK("rotated", rotated.Public().(ed25519.PublicKey)))
+	keys.jwks.gate = make(chan struct{})
+	clock = clock.Add(5 * time.Minute)
+
+	results := make(chan error)
+	get := func(kid string) {
+		_, err := set.Get(context.Background(), kid)
+		results <- err
+	}
+
+	// The first request after the keys expire fetches them...
+	go get("ed")
+	<-keys.jwks.gate
+
+	// ...while the others are served the cached keys, or wait for the fetch
+	// if they need a key that isn't cached.
+	if _, err := set.Get(context.Background(), "ed"); err != nil {
+		t.Fatalf("Get(cached kid) during a fetch = %v", err)
+	}
+
+	go get("rotated")
+	go get("rotated")
+
+	keys.jwks.gate <- struct{}{}
+	for range 3 {
+		if err := <-results; err != nil {
+			t.Errorf("Get = %v", err)
+		}
+	}
+
+	if calls := keys.jwks.calls.Load(); calls != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", calls)
+	}
+}
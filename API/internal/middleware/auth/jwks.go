@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"api/internal/domain/models"
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often the keys may be refetched, whether the
+// previous attempt succeeded or not, so tokens with made up key ids or an
+// unavailable UsersService can't turn every request into a JWKS call.
+const minRefreshInterval = 10 * time.Second
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type JWKSProvider interface {
+	GetJWKS(context.Context) ([]models.JWK, error)
+}
+
+// keySet caches the public keys UsersService signs access tokens with.
+type keySet struct {
+	provider JWKSProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	err         error
+	// refreshing is closed once the fetch in flight is done, nil if there's none.
+	refreshing chan struct{}
+}
+
+func newKeySet(provider JWKSProvider, ttl time.Duration) *keySet {
+	return &keySet{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		keys:     make(map[string]any),
+	}
+}
+
+// Get returns the key with the id. The keys are refetched when they expire or
+// the id is unknown, at most once per minRefreshInterval and without holding
+// the lock, so cached keys keep being served while a fetch is in flight.
+func (k *keySet) Get(ctx context.Context, kid string) (any, error) {
+	k.mu.Lock()
+	key, ok := k.keys[kid]
+	now := k.now()
+
+	if ok && now.Sub(k.fetchedAt) < k.ttl {
+		k.mu.Unlock()
+		return key, nil
+	}
+
+	done := k.refreshing
+	fetch := done == nil && now.Sub(k.attemptedAt) >= minRefreshInterval
+	if fetch {
+		done = make(chan struct{})
+		k.refreshing = done
+		k.attemptedAt = now
+	}
+	k.mu.Unlock()
+
+	switch {
+	case fetch:
+		k.refresh(ctx, done)
+	case ok:
+		// Stale keys are still better than waiting for UsersService or
+		// failing every request while it is unavailable.
+		return key, nil
+	case done == nil:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	default:
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	if k.err != nil {
+		return nil, k.err
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+func (k *keySet) refresh(ctx context.Context, done chan struct{}) {
+	keys, err := k.fetch(ctx)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err == nil {
+		k.keys = keys
+		k.fetchedAt = k.now()
+	}
+	k.err = err
+	k.refreshing = nil
+	close(done)
+}
+
+func (k *keySet) fetch(ctx context.Context) (map[string]any, error) {
+	const op = "auth.keySet.fetch"
+
+	jwks, err := k.provider.GetJWKS(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make(map[string]any, len(jwks))
+	for _, jwk := range jwks {
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseJWK(jwk models.JWK) (any, error) {
+	switch jwk.Kty {
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
@@ -0,0 +1,165 @@
+package resilience
+
+import (
+	"api/pkg/config"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+// OpenError is returned instead of calling UsersService while the circuit
+// breaker is open.
+type OpenError struct {
+	retryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return "circuit breaker is open"
+}
+
+// RetryAfter is how long until the breaker lets requests through again.
+func (e *OpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// CircuitBreaker stops calling UsersService after FailureThreshold
+// consecutive failures. Once OpenTimeout passes, HalfOpenRequests probes are
+// let through: a success closes the breaker, a failure opens it again.
+type CircuitBreaker struct {
+	log *slog.Logger
+	cfg config.CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func NewCircuitBreaker(log *slog.Logger, cfg config.CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		log: log,
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := b.allow(); err != nil {
+			return err
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(ctx, err)
+
+		return err
+	}
+}
+
+// Stream guards opening streams; failures after a stream is open aren't counted.
+func (b *CircuitBreaker) Stream() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := b.allow(); err != nil {
+			return nil, err
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		b.record(ctx, err)
+
+		return stream, err
+	}
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if since := b.now().Sub(b.openedAt); since < b.cfg.OpenTimeout {
+			return &OpenError{retryAfter: b.cfg.OpenTimeout - since}
+		}
+
+		b.state = halfOpen
+		b.probes = 0
+		fallthrough
+	case halfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			return &OpenError{retryAfter: time.Second}
+		}
+
+		b.probes++
+	}
+
+	return nil
+}
+
+func (b *CircuitBreaker) record(ctx context.Context, err error) {
+	const op = "resilience.CircuitBreaker.record"
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The caller gave up, that says nothing about UsersService.
+	if ctx.Err() != nil {
+		if b.state == halfOpen && b.probes > 0 {
+			b.probes--
+		}
+		return
+	}
+
+	if !failure(err) {
+		// A call started before the breaker opened doesn't prove recovery.
+		if b.state == open {
+			return
+		}
+
+		if b.state == halfOpen {
+			b.log.Info("circuit breaker closed", slog.String("op", op))
+		}
+
+		b.state = closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == halfOpen || b.failures >= b.cfg.FailureThreshold {
+		if b.state != open {
+			b.log.Warn("circuit breaker opened",
+				slog.String("op", op),
+				slog.Int("failures", b.failures),
+				slog.Duration("open_timeout", b.cfg.OpenTimeout),
+			)
+		}
+
+		b.state = open
+		b.openedAt = b.now()
+	}
+}
+
+// failure reports whether err means UsersService is down or overloaded,
+// as opposed to rejecting the request.
+func failure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
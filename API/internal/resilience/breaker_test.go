@@ -0,0 +1,168 @@
+package resilience
+
+import (
+	"api/pkg/config"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "unavailable")
+	errRejected    = status.Error(codes.InvalidArgument, "invalid argument")
+)
+
+// testBreaker is a CircuitBreaker on a clock that only moves when told to.
+type testBreaker struct {
+	*CircuitBreaker
+	clock time.Time
+	calls int
+}
+
+func newTestBreaker(cfg config.CircuitBreakerConfig) *testBreaker {
+	b := &testBreaker{
+		CircuitBreaker: NewCircuitBreaker(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg),
+		clock:          time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	b.now = func() time.Time { return b.clock }
+
+	return b
+}
+
+// call sends a unary RPC through the breaker to an invoker failing with err.
+func (b *testBreaker) call(ctx context.Context, err error) error {
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		b.calls++
+		return err
+	}
+
+	return b.Unary()(ctx, "/users.UsersService/GetUsers", nil, nil, nil, invoker)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	cfg := config.CircuitBreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+
+	// step is a call made after advancing the clock.
+	type step struct {
+		advance  time.Duration
+		err      error
+		rejected bool
+	}
+
+	tests := []struct {
+		name      string
+		steps     []step
+		wantState state
+	}{
+		{
+			name:      "opens after consecutive failures",
+			steps:     []step{{err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}, {rejected: true}},
+			wantState: open,
+		},
+		{
+			name:      "a success resets the failures",
+			steps:     []step{{err: errUnavailable}, {err: errUnavailable}, {}, {err: errUnavailable}, {err: errUnavailable}},
+			wantState: closed,
+		},
+		{
+			name:      "rejected requests aren't failures",
+			steps:     []step{{err: errRejected}, {err: errRejected}, {err: errRejected}, {err: errRejected}},
+			wantState: closed,
+		},
+		{
+			name:      "stays open until the timeout",
+			steps:     []step{{err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}, {advance: 29 * time.Second, rejected: true}},
+			wantState: open,
+		},
+		{
+			name:      "a successful probe closes",
+			steps:     []step{{err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}, {advance: 30 * time.Second}, {}},
+			wantState: closed,
+		},
+		{
+			name:      "a failed probe opens again",
+			steps:     []step{{err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}, {advance: 30 * time.Second, err: errUnavailable}, {rejected: true}},
+			wantState: open,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBreaker(cfg)
+
+			for i, step := range tt.steps {
+				b.clock = b.clock.Add(step.advance)
+				calls := b.calls
+
+				err := b.call(context.Background(), step.err)
+
+				var openErr *OpenError
+				if rejected := errors.As(err, &openErr); rejected != step.rejected {
+					t.Fatalf("step %d: err = %v, want rejected = %v", i, err, step.rejected)
+				}
+				if called := b.calls > calls; called == step.rejected {
+					t.Fatalf("step %d: invoker called = %v, want %v", i, called, !step.rejected)
+				}
+			}
+
+			if b.state != tt.wantState {
+				t.Errorf("state = %v, want %v", b.state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	b := newTestBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 30 * time.Second, HalfOpenRequests: 1})
+	b.call(context.Background(), errUnavailable)
+	b.clock = b.clock.Add(10 * time.Second)
+
+	var openErr *OpenError
+	if err := b.call(context.Background(), nil); !errors.As(err, &openErr) {
+		t.Fatalf("err = %v, want *OpenError", err)
+	}
+	if got, want := openErr.RetryAfter(), 20*time.Second; got != want {
+		t.Errorf("RetryAfter() = %v, want %v", got, want)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	for _, probes := range []int{1, 3} {
+		b := newTestBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenRequests: probes})
+		b.call(context.Background(), errUnavailable)
+		b.clock = b.clock.Add(time.Second)
+
+		// Probes in flight haven't recorded a result yet.
+		for i := range probes {
+			if err := b.allow(); err != nil {
+				t.Fatalf("HalfOpenRequests = %d: probe %d rejected: %v", probes, i, err)
+			}
+		}
+		if err := b.allow(); err == nil {
+			t.Errorf("HalfOpenRequests = %d: probe %d let through", probes, probes)
+		}
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledCalls(t *testing.T) {
+	b := newTestBreaker(config.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second, HalfOpenRequests: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.call(ctx, status.Error(codes.DeadlineExceeded, "canceled"))
+
+	if b.state != closed {
+		t.Errorf("state = %v after a canceled call, want closed", b.state)
+	}
+}
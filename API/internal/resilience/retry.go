@@ -0,0 +1,162 @@
+package resilience
+
+import (
+	"api/pkg/config"
+	"context"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Idempotent reports whether the RPC only reads state and is safe to send
+// more than once: its name starts with Get or List.
+func Idempotent(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
+
+// retryable reports whether another attempt may succeed where err failed.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// Retrier retries idempotent unary RPCs failing with Unavailable or
+// DeadlineExceeded, with exponential backoff and full jitter, and
+// optionally hedges slow attempts.
+type Retrier struct {
+	log     *slog.Logger
+	cfg     config.RetryConfig
+	hedging config.HedgingConfig
+}
+
+func NewRetrier(log *slog.Logger, cfg config.RetryConfig, hedging config.HedgingConfig) *Retrier {
+	return &Retrier{
+		log:     log,
+		cfg:     cfg,
+		hedging: hedging,
+	}
+}
+
+func (r *Retrier) Unary() grpc.UnaryClientInterceptor {
+	const op = "resilience.Retrier.Unary"
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		msg, ok := reply.(proto.Message)
+		if !ok || !Idempotent(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		log := r.log.With(
+			"op", op,
+			slog.String("method", method),
+		)
+
+		for attempt := 1; ; attempt++ {
+			err := r.hedged(ctx, method, req, msg, cc, invoker, opts)
+			if err == nil || !retryable(err) || attempt >= r.cfg.MaxAttempts || ctx.Err() != nil {
+				return err
+			}
+
+			backoff := r.backoff(attempt)
+			log.WarnContext(ctx, "retrying rpc",
+				slog.Int("attempt", attempt),
+				slog.Duration("backoff", backoff),
+				slog.String("code", status.Code(err).String()),
+			)
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// hedged runs an attempt and, if it's still running after the hedging
+// delay, a second one alongside it. The first attempt that succeeds or fails
+// for good wins and the other one is canceled.
+func (r *Retrier) hedged(ctx context.Context, method string, req any, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) error {
+	if r.hedging.Delay <= 0 {
+		return r.attempt(ctx, method, req, reply, cc, invoker, opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+
+	// Buffered for both attempts, so the loser never blocks.
+	results := make(chan result, 2)
+	call := func() {
+		reply := reply.ProtoReflect().New().Interface()
+		err := r.attempt(ctx, method, req, reply, cc, invoker, opts)
+		results <- result{reply, err}
+	}
+
+	go call()
+	pending := 1
+
+	timer := time.NewTimer(r.hedging.Delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			pending++
+			go call()
+		case res := <-results:
+			pending--
+			if res.err != nil && retryable(res.err) && pending > 0 {
+				continue
+			}
+
+			if res.err == nil {
+				proto.Merge(reply, res.reply)
+			}
+
+			return res.err
+		}
+	}
+}
+
+func (r *Retrier) attempt(ctx context.Context, method string, req any, reply proto.Message, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) error {
+	if r.cfg.PerAttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.PerAttemptTimeout)
+		defer cancel()
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// backoff returns a random duration up to the exponential backoff of the attempt.
+func (r *Retrier) backoff(attempt int) time.Duration {
+	backoff := float64(r.cfg.InitialBackoff) * math.Pow(r.cfg.Multiplier, float64(attempt-1))
+	if backoff > float64(r.cfg.MaxBackoff) {
+		backoff = float64(r.cfg.MaxBackoff)
+	}
+
+	if backoff < 1 {
+		return 0
+	}
+
+	return time.Duration(rand.Int64N(int64(backoff)))
+}
@@ -0,0 +1,160 @@
+package resilience
+
+import (
+	"api/pkg/config"
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"/users.UsersService/GetUsers", true},
+		{"/users.UsersService/GetUserById", true},
+		{"/users.UsersService/ListSessions", true},
+		{"GetJWKS", true},
+		{"/users.UsersService/InsertUser", false},
+		{"/users.UsersService/Authenticate", false},
+		{"/users.UsersService/RevokeSession", false},
+		{"/users.Getter/DeleteUser", false},
+	}
+
+	for _, tt := range tests {
+		if got := Idempotent(tt.method); got != tt.want {
+			t.Errorf("Idempotent(%q) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
+
+func newTestRetrier(cfg config.RetryConfig, hedging config.HedgingConfig) *Retrier {
+	return NewRetrier(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, hedging)
+}
+
+func TestRetrier(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		results   []codes.Code
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{"retries until success", "/u.S/GetUsers", []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.OK}, 3, codes.OK},
+		{"gives up after max attempts", "/u.S/GetUsers", []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.OK}, 3, codes.Unavailable},
+		{"doesn't retry rejections", "/u.S/GetUsers", []codes.Code{codes.InvalidArgument, codes.OK}, 1, codes.InvalidArgument},
+		{"doesn't retry writes", "/u.S/InsertUser", []codes.Code{codes.Unavailable, codes.OK}, 1, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRetrier(config.RetryConfig{MaxAttempts: 3, Multiplier: 2}, config.HedgingConfig{})
+
+			var calls int
+			invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+				code := tt.results[calls]
+				calls++
+				return status.Error(code, code.String())
+			}
+
+			err := r.Unary()(context.Background(), tt.method, nil, &wrapperspb.StringValue{}, nil, invoker)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("code = %v, want %v", code, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	r := newTestRetrier(config.RetryConfig{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}, config.HedgingConfig{})
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{20, time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 1000 {
+			if got := r.backoff(tt.attempt); got < 0 || got >= tt.max {
+				t.Fatalf("backoff(%d) = %v, want in [0, %v)", tt.attempt, got, tt.max)
+			}
+		}
+	}
+
+	if got := newTestRetrier(config.RetryConfig{Multiplier: 2}, config.HedgingConfig{}).backoff(1); got != 0 {
+		t.Errorf("backoff without initial backoff = %v, want 0", got)
+	}
+}
+
+func TestHedging(t *testing.T) {
+	hedging := config.HedgingConfig{Delay: 10 * time.Millisecond}
+
+	t.Run("the hedge wins over a slow attempt", func(t *testing.T) {
+		r := newTestRetrier(config.RetryConfig{MaxAttempts: 1}, hedging)
+
+		var calls atomic.Int32
+		invoker := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+
+			reply.(*wrapperspb.StringValue).Value = "hedged"
+			return nil
+		}
+
+		reply := &wrapperspb.StringValue{}
+		if err := r.Unary()(context.Background(), "/u.S/GetUsers", nil, reply, nil, invoker); err != nil {
+			t.Fatalf("err = %v", err)
+		}
+		if reply.GetValue() != "hedged" {
+			t.Errorf("reply = %q, want the hedge's", reply.GetValue())
+		}
+	})
+
+	t.Run("a single hedge is sent", func(t *testing.T) {
+		r := newTestRetrier(config.RetryConfig{MaxAttempts: 1}, hedging)
+
+		var calls atomic.Int32
+		invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			calls.Add(1)
+			<-ctx.Done()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		// Ten times the hedging delay.
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err := r.Unary()(ctx, "/u.S/GetUsers", nil, &wrapperspb.StringValue{}, nil, invoker)
+		if code := status.Code(err); code != codes.DeadlineExceeded {
+			t.Errorf("code = %v, want DeadlineExceeded", code)
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("calls = %d, want 2", got)
+		}
+	})
+}
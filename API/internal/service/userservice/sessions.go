@@ -0,0 +1,135 @@
+package userservice
+
+import (
+	"api/internal/domain/models"
+	serviceerror "api/internal/service"
+	storageerror "api/internal/storage"
+	"api/pkg/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// RefreshSession implements service.IUserService.
+func (u *UserService) RefreshSession(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error) {
+	const op = "service.user.RefreshSession"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tokens, err := u.storage.RefreshSession(ctx, refreshToken, client)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidCredentials) {
+			log.WarnContext(ctx, "invalid refresh token", sl.Err(err))
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidCredentials)
+		}
+
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.Tokens{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot refresh session", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// ListSessions implements service.IUserService.
+func (u *UserService) ListSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	const op = "service.user.ListSessions"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	sessions, err := u.storage.ListSessions(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession implements service.IUserService.
+func (u *UserService) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) (models.Session, error) {
+	const op = "service.user.RevokeSession"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	session, err := u.storage.RevokeSession(ctx, userId, sessionId)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "session not found", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot revoke session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// RevokeSessions implements service.IUserService.
+func (u *UserService) RevokeSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	const op = "service.user.RevokeSessions"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	revoked, err := u.storage.RevokeSessions(ctx, userId)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return 0, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot revoke sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
@@ -27,7 +27,7 @@ func New(log *slog.Logger, storage storage.IUserStorage) *UserService {
 }
 
 // GetUsers implements service.IUserService.
-func (u *UserService) GetUsers(ctx context.Context) ([]models.User, error) {
+func (u *UserService) GetUsers(ctx context.Context, query models.UsersQuery) (models.UsersPage, error) {
 	const op = "service.user.GetUsers"
 	log := u.log.With(
 		"op", op,
@@ -35,23 +35,65 @@ func (u *UserService) GetUsers(ctx context.Context) ([]models.User, error) {
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
-		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.UsersPage{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	users, err := u.storage.GetUsers(ctx)
+	page, err := u.storage.GetUsers(ctx, query)
 	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid users query", sl.Err(err))
+			return models.UsersPage{}, fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("users not found", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+			log.WarnContext(ctx, "users not found", sl.Err(err))
+			return models.UsersPage{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("caannot fetch users", sl.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.UsersPage{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "caannot fetch users", sl.Err(err))
+		return models.UsersPage{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 	}
 
-	return users, nil
+	return page, nil
+}
+
+// StreamUsers implements service.IUserService.
+func (u *UserService) StreamUsers(ctx context.Context, query models.UsersQuery, fn func(models.User) error) error {
+	const op = "service.user.StreamUsers"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	if err := u.storage.StreamUsers(ctx, query, fn); err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid users query", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot stream users", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
 // GetUserById implements service.IUserService.
@@ -63,19 +105,29 @@ func (u *UserService) GetUserById(ctx context.Context, id uuid.UUID) (models.Use
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	user, err := u.storage.GetUserById(ctx, id)
 	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid request", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot get user by id", sl.Err(err))
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot get user by id", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -91,19 +143,34 @@ func (u *UserService) InsertUser(ctx context.Context, user models.User) (models.
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	user, err := u.storage.InsertUser(ctx, user)
 	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid request", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
+		if errors.Is(err, storageerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrLoginTaken)
+		}
+
 		if errors.Is(err, storageerror.ErrAlreadyExists) {
-			log.Warn("user already exists", sl.Err(err))
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrAlreadyExists)
 		}
 
-		log.Error("cannot insert user", sl.Err(err))
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -118,19 +185,34 @@ func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user models.
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out")
+		log.ErrorContext(ctx, "request time out")
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	user, err := u.storage.UpdateUser(ctx, id, user)
 	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid request", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(ctx, "user not found", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot update user", sl.Err(err))
+		if errors.Is(err, storageerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrLoginTaken)
+		}
+
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot update user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -146,21 +228,103 @@ func (u *UserService) DeleteUser(ctx context.Context, id uuid.UUID) (models.User
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	user, err := u.storage.DeleteUser(ctx, id)
 	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidArgument) {
+			log.WarnContext(ctx, "invalid request", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, invalidArgument(err))
+		}
+
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(ctx, "user not found", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot delete user", sl.Err(err))
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return user, nil
 }
+
+// Authenticate implements service.IUserService.
+func (u *UserService) Authenticate(ctx context.Context, login, password string, client models.ClientInfo) (models.User, models.Tokens, error) {
+	const op = "service.user.Authenticate"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, tokens, err := u.storage.Authenticate(ctx, login, password, client)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrInvalidCredentials) {
+			log.WarnContext(ctx, "invalid credentials", sl.Err(err))
+			return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidCredentials)
+		}
+
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot authenticate user", sl.Err(err))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, tokens, nil
+}
+
+// GetJWKS implements service.IUserService.
+func (u *UserService) GetJWKS(ctx context.Context) ([]models.JWK, error) {
+	const op = "service.user.GetJWKS"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	jwks, err := u.storage.GetJWKS(ctx)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrUnavailable) {
+			log.WarnContext(ctx, "users service unavailable", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w: %w", op, serviceerror.ErrUnavailable, err)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch jwks", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jwks, nil
+}
+
+// invalidArgument carries the field violations of a storage error over to
+// the service one.
+func invalidArgument(err error) error {
+	var invalid *storageerror.InvalidArgumentError
+	if errors.As(err, &invalid) {
+		return &serviceerror.InvalidArgumentError{Violations: invalid.Violations}
+	}
+
+	return serviceerror.ErrInvalidArgument
+}
@@ -1,8 +1,39 @@
 package storage
 
-import "errors"
+import (
+	"api/internal/domain/models"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrLoginTaken is the ErrAlreadyExists of a user whose login belongs
+	// to another one.
+	ErrLoginTaken = fmt.Errorf("login is taken: %w", ErrAlreadyExists)
+
+	ErrInvalidCredentials = errors.New("invalid login or password")
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrUnavailable        = errors.New("users service unavailable")
 )
+
+// InvalidArgumentError is the ErrInvalidArgument of a request whose fields
+// UsersService rejected, listing each of them.
+type InvalidArgumentError struct {
+	Violations []models.FieldViolation
+}
+
+func (e *InvalidArgumentError) Error() string {
+	fields := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		fields = append(fields, violation.Field+": "+violation.Description)
+	}
+
+	return ErrInvalidArgument.Error() + ": " + strings.Join(fields, ", ")
+}
+
+func (e *InvalidArgumentError) Unwrap() error {
+	return ErrInvalidArgument
+}
@@ -0,0 +1,28 @@
+package userstorage
+
+import (
+	umv1 "api/proto/gen"
+	"context"
+	"fmt"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health asks UsersService over the shared connection whether it serves
+// requests, which it does only while its database is reachable.
+func (g *GRPCUserServer) Health(ctx context.Context) error {
+	const op = "storage.user.Health"
+
+	res, err := healthpb.NewHealthClient(g.conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: umv1.UsersService_ServiceDesc.ServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if res.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%s: users service is %s", op, res.GetStatus())
+	}
+
+	return nil
+}
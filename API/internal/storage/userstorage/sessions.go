@@ -0,0 +1,116 @@
+package userstorage
+
+import (
+	"api/internal/domain/models"
+	"api/internal/domain/profiles"
+	"api/pkg/logger/sl"
+	umv1 "api/proto/gen"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// RefreshSession implements storage.IUserStorage.
+func (g *GRPCUserServer) RefreshSession(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error) {
+	const op = "storage.user.RefreshSession"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.RefreshSession(ctx, &umv1.RefreshSessionRequest{
+		RefreshToken: refreshToken,
+		UserAgent:    client.UserAgent,
+		Ip:           client.IP,
+	})
+	if err != nil {
+		return models.Tokens{}, g.handleError(err, op)
+	}
+
+	return profiles.ProtoTokensToTokens(res.GetTokens()), nil
+}
+
+// ListSessions implements storage.IUserStorage.
+func (g *GRPCUserServer) ListSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	const op = "storage.user.ListSessions"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.ListSessions(ctx, &umv1.ListSessionsRequest{
+		UserId: userId.String(),
+	})
+	if err != nil {
+		return nil, g.handleError(err, op)
+	}
+
+	var sessions = make([]models.Session, 0, len(res.GetSessions()))
+	for _, session := range res.GetSessions() {
+		sessions = append(sessions, profiles.ProtoSessionToSession(session))
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession implements storage.IUserStorage.
+func (g *GRPCUserServer) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) (models.Session, error) {
+	const op = "storage.user.RevokeSession"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.RevokeSession(ctx, &umv1.RevokeSessionRequest{
+		UserId:    userId.String(),
+		SessionId: sessionId.String(),
+	})
+	if err != nil {
+		return models.Session{}, g.handleError(err, op)
+	}
+
+	return profiles.ProtoSessionToSession(res.GetSession()), nil
+}
+
+// RevokeSessions implements storage.IUserStorage.
+func (g *GRPCUserServer) RevokeSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	const op = "storage.user.RevokeSessions"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.RevokeSessions(ctx, &umv1.RevokeSessionsRequest{
+		UserId: userId.String(),
+	})
+	if err != nil {
+		return 0, g.handleError(err, op)
+	}
+
+	return res.GetRevoked(), nil
+}
@@ -3,37 +3,104 @@ package userstorage
 import (
 	"api/internal/domain/models"
 	"api/internal/domain/profiles"
+	"api/internal/resilience"
 	storageerror "api/internal/storage"
+	"api/pkg/config"
 	"api/pkg/logger/sl"
 	umv1 "api/proto/gen"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// staticScheme is the resolver scheme of a static address list.
+const staticScheme = "static"
+
+// GRPCUserServer talks to UsersService over a single client connection
+// created in New and shared by all calls. Close it on shutdown.
 type GRPCUserServer struct {
-	log  *slog.Logger
-	host string
-	port int
+	log    *slog.Logger
+	conn   *grpc.ClientConn
+	client umv1.UsersServiceClient
 }
 
-func New(log *slog.Logger, host string, port int) *GRPCUserServer {
-	return &GRPCUserServer{
-		log:  log,
-		host: host,
-		port: port,
+// New creates the connection to UsersService. Extra opts are applied after
+// the configured ones, in-process tests pass a dialer through them.
+func New(log *slog.Logger, cfg *config.Config, extra ...grpc.DialOption) (*GRPCUserServer, error) {
+	const op = "storage.user.New"
+
+	// The breaker is the outer interceptor, so it sees the outcome after
+	// retries and an open breaker fails fast without retrying.
+	breaker := resilience.NewCircuitBreaker(log, cfg.UsersService.CircuitBreaker)
+	retrier := resilience.NewRetrier(log, cfg.UsersService.Retry, cfg.UsersService.Hedging)
+
+	opts := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(breaker.Unary(), retrier.Unary()),
+		grpc.WithChainStreamInterceptor(breaker.Stream()),
+		// Every attempt made by the retrier gets its own client span.
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.UsersService.Keepalive.Time,
+			Timeout:             cfg.UsersService.Keepalive.Timeout,
+			PermitWithoutStream: cfg.UsersService.Keepalive.PermitWithoutStream,
+		}),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(
+			`{"loadBalancingConfig": [{%q: {}}]}`, cfg.UsersService.LoadBalancing,
+		)),
+	}
+
+	// The dns resolver hands every A record to the balancer, so
+	// replicas behind one name are balanced as well as a static list.
+	target := fmt.Sprintf("dns:///%s:%d", cfg.ServerHost, cfg.ServerPort)
+	if len(cfg.UsersService.Addresses) > 0 {
+		addresses := make([]resolver.Address, 0, len(cfg.UsersService.Addresses))
+		for _, addr := range cfg.UsersService.Addresses {
+			addresses = append(addresses, resolver.Address{Addr: addr})
+		}
+
+		r := manual.NewBuilderWithScheme(staticScheme)
+		r.InitialState(resolver.State{Addresses: addresses})
+
+		target = staticScheme + ":///users-service"
+		opts = append(opts, grpc.WithResolvers(r))
+	}
+	opts = append(opts, extra...)
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		log.Error("Failed to create gRPC client", slog.String("target", target), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+
+	return &GRPCUserServer{
+		log:    log,
+		conn:   conn,
+		client: umv1.NewUsersServiceClient(conn),
+	}, nil
+}
+
+// Close closes the connection to UsersService.
+func (g *GRPCUserServer) Close() error {
+	return g.conn.Close()
 }
 
 // GetUsers implements storage.IUserStorage.
-func (g *GRPCUserServer) GetUsers(ctx context.Context) ([]models.User, error) {
+func (g *GRPCUserServer) GetUsers(ctx context.Context, query models.UsersQuery) (models.UsersPage, error) {
 	const op = "storage.user.GetUsers"
 	log := g.log.With(
 		"op", op,
@@ -41,33 +108,60 @@ func (g *GRPCUserServer) GetUsers(ctx context.Context) ([]models.User, error) {
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
-		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.UsersPage{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", g.host, g.port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		log.Error("Failed to connect to gRPC server", sl.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	defer conn.Close()
-
-	c := umv1.NewUsersServiceClient(conn)
-	res, err := c.GetUsers(ctx, &emptypb.Empty{})
+	res, err := g.client.GetUsers(ctx, profiles.UsersQueryToProtoGetUsersRequest(query))
 	if err != nil {
-		return nil, g.handleError(err, op)
+		return models.UsersPage{}, g.handleError(err, op)
 	}
 
-	var resUsers = make([]models.User, 0, 5)
+	var resUsers = make([]models.User, 0, len(res.GetUsers()))
 	for _, user := range res.GetUsers() {
 		resUsers = append(resUsers, profiles.ProtoUserToUser(user))
 	}
 
-	return resUsers, nil
+	return models.UsersPage{
+		Users:         resUsers,
+		NextPageToken: res.GetNextPageToken(),
+	}, nil
+}
+
+// StreamUsers implements storage.IUserStorage. Only the filters of the query
+// are used, users come in creation order and are passed to fn as they arrive.
+func (g *GRPCUserServer) StreamUsers(ctx context.Context, query models.UsersQuery, fn func(models.User) error) error {
+	const op = "storage.user.StreamUsers"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	stream, err := g.client.StreamUsers(ctx, profiles.UsersQueryToProtoStreamUsersRequest(query))
+	if err != nil {
+		return g.handleError(err, op)
+	}
+
+	for {
+		user, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return g.handleError(err, op)
+		}
+
+		if err := fn(profiles.ProtoUserToUser(user)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
 }
 
 // GetUserById implements storage.IUserStorage.
@@ -79,23 +173,12 @@ func (g *GRPCUserServer) GetUserById(ctx context.Context, id uuid.UUID) (models.
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", g.host, g.port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		log.Error("Failed to connect to gRPC server", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
-	defer conn.Close()
-
-	c := umv1.NewUsersServiceClient(conn)
-	res, err := c.GetUserById(ctx, &umv1.GetUserByIdRequest{
+	res, err := g.client.GetUserById(ctx, &umv1.GetUserByIdRequest{
 		Id: id.String(),
 	})
 	if err != nil {
@@ -114,23 +197,12 @@ func (g *GRPCUserServer) InsertUser(ctx context.Context, user models.User) (mode
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", g.host, g.port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		log.Error("Failed to connect to gRPC server", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
-	defer conn.Close()
-
-	c := umv1.NewUsersServiceClient(conn)
-	res, err := c.InsertUser(ctx, &umv1.InsertRequest{
+	res, err := g.client.InsertUser(ctx, &umv1.InsertRequest{
 		User: profiles.UserToProtoUser(user),
 	})
 	if err != nil {
@@ -148,23 +220,12 @@ func (g *GRPCUserServer) UpdateUser(ctx context.Context, id uuid.UUID, user mode
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", g.host, g.port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		log.Error("Failed to connect to gRPC server", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
-	defer conn.Close()
-
-	c := umv1.NewUsersServiceClient(conn)
-	res, err := c.UpdateUser(ctx,
+	res, err := g.client.UpdateUser(ctx,
 		&umv1.UpdateRequest{
 			Id:   id.String(),
 			User: profiles.UserToProtoUser(user),
@@ -185,23 +246,12 @@ func (g *GRPCUserServer) DeleteUser(ctx context.Context, id uuid.UUID) (models.U
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	conn, err := grpc.NewClient(
-		fmt.Sprintf("%s:%d", g.host, g.port),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		log.Error("Failed to connect to gRPC server", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
-	defer conn.Close()
-
-	c := umv1.NewUsersServiceClient(conn)
-	res, err := c.DeleteUser(ctx, &umv1.DeleteResuest{
+	res, err := g.client.DeleteUser(ctx, &umv1.DeleteResuest{
 		Id: id.String(),
 	})
 	if err != nil {
@@ -211,15 +261,95 @@ func (g *GRPCUserServer) DeleteUser(ctx context.Context, id uuid.UUID) (models.U
 	return profiles.ProtoUserToUser(res.GetUser()), nil
 }
 
+// Authenticate implements storage.IUserStorage.
+func (g *GRPCUserServer) Authenticate(ctx context.Context, login, password string, client models.ClientInfo) (models.User, models.Tokens, error) {
+	const op = "storage.user.Authenticate"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.Authenticate(ctx, &umv1.AuthenticateRequest{
+		Login:     login,
+		Password:  password,
+		UserAgent: client.UserAgent,
+		Ip:        client.IP,
+	})
+	if err != nil {
+		return models.User{}, models.Tokens{}, g.handleError(err, op)
+	}
+
+	return profiles.ProtoUserToUser(res.GetUser()), profiles.ProtoTokensToTokens(res.GetTokens()), nil
+}
+
+// GetJWKS implements storage.IUserStorage.
+func (g *GRPCUserServer) GetJWKS(ctx context.Context) ([]models.JWK, error) {
+	const op = "storage.user.GetJWKS"
+	log := g.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	res, err := g.client.GetJWKS(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, g.handleError(err, op)
+	}
+
+	var jwks = make([]models.JWK, 0, len(res.GetKeys()))
+	for _, jwk := range res.GetKeys() {
+		jwks = append(jwks, profiles.ProtoJWKToJWK(jwk))
+	}
+
+	return jwks, nil
+}
+
 func (g *GRPCUserServer) handleError(err error, operation string) error {
+	var openErr *resilience.OpenError
+	if errors.As(err, &openErr) {
+		g.log.Warn("users service circuit is open", sl.Err(err))
+		return fmt.Errorf("%s: %w: %w", operation, storageerror.ErrUnavailable, err)
+	}
+
 	if st, ok := status.FromError(err); ok {
 		switch st.Code() {
 		case codes.NotFound:
 			g.log.Warn("users not found", sl.Err(err))
 			return fmt.Errorf("%s: %w", operation, storageerror.ErrNotFound)
 		case codes.AlreadyExists:
+			if violatedField(st) == fieldLogin {
+				g.log.Warn("login is taken", sl.Err(err))
+				return fmt.Errorf("%s: %w", operation, storageerror.ErrLoginTaken)
+			}
+
 			g.log.Warn("users already exists", sl.Err(err))
 			return fmt.Errorf("%s: %w", operation, storageerror.ErrAlreadyExists)
+		case codes.Unauthenticated:
+			g.log.Warn("invalid credentials", sl.Err(err))
+			return fmt.Errorf("%s: %w", operation, storageerror.ErrInvalidCredentials)
+		case codes.Unavailable, codes.DeadlineExceeded:
+			// A call running out of time is as transient as an unreachable
+			// UsersService: both are worth retrying later.
+			g.log.Warn("users service unavailable", sl.Err(err))
+			return fmt.Errorf("%s: %w: %w", operation, storageerror.ErrUnavailable, err)
+		case codes.InvalidArgument:
+			g.log.Warn("invalid argument", sl.Err(err))
+			if violations := fieldViolations(st); len(violations) > 0 {
+				return fmt.Errorf("%s: %w", operation, &storageerror.InvalidArgumentError{Violations: violations})
+			}
+
+			return fmt.Errorf("%s: %w: %s", operation, storageerror.ErrInvalidArgument, st.Message())
 		default:
 			g.log.Error("gRPC error occurred", sl.Err(err))
 			return fmt.Errorf("%s: %w", operation, err)
@@ -227,3 +357,33 @@ func (g *GRPCUserServer) handleError(err error, operation string) error {
 	}
 	return fmt.Errorf("%s: %w", operation, err)
 }
+
+// fieldLogin is the field UsersService names when a login is taken.
+const fieldLogin = "login"
+
+// violatedField returns the first field named by a BadRequest detail of st,
+// or "" if there is none.
+func violatedField(st *status.Status) string {
+	if violations := fieldViolations(st); len(violations) > 0 {
+		return violations[0].Field
+	}
+
+	return ""
+}
+
+// fieldViolations returns the fields named by the BadRequest details of st.
+func fieldViolations(st *status.Status) []models.FieldViolation {
+	var violations []models.FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				violations = append(violations, models.FieldViolation{
+					Field:       violation.GetField(),
+					Description: violation.GetDescription(),
+				})
+			}
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,42 @@
+package userstorage
+
+import (
+	"api/internal/resilience"
+	storageerror "api/internal/storage"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandleError(t *testing.T) {
+	g := &GRPCUserServer{log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), storageerror.ErrUnavailable},
+		{"deadline exceeded", status.Error(codes.DeadlineExceeded, "deadline exceeded"), storageerror.ErrUnavailable},
+		{"open circuit", &resilience.OpenError{}, storageerror.ErrUnavailable},
+		{"not found", status.Error(codes.NotFound, "user not found"), storageerror.ErrNotFound},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "invalid credentials"), storageerror.ErrInvalidCredentials},
+		{"invalid argument", status.Error(codes.InvalidArgument, "invalid id"), storageerror.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := g.handleError(tt.err, "op"); !errors.Is(err, tt.want) {
+				t.Errorf("handleError(%v) = %v, want %v", tt.err, err, tt.want)
+			}
+		})
+	}
+
+	if err := g.handleError(status.Error(codes.Internal, "boom"), "op"); errors.Is(err, storageerror.ErrUnavailable) {
+		t.Errorf("handleError(Internal) = %v, want it not to be retried later", err)
+	}
+}
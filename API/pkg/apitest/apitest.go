@@ -0,0 +1,37 @@
+// Package apitest exposes the API handler to end to end tests living
+// outside of this module.
+package apitest
+
+import (
+	"api/internal/app"
+	"api/pkg/config"
+	"context"
+	"log/slog"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// API is the API wired as in cmd/app, without listening on a port.
+type API struct {
+	app *app.App
+}
+
+// New wires the API. opts are passed on to the connection to UsersService,
+// e.g. grpc.WithContextDialer to reach an in-process server.
+func New(log *slog.Logger, cfg *config.Config, opts ...grpc.DialOption) *API {
+	return &API{
+		app: app.New(log, cfg, opts...),
+	}
+}
+
+// Handler returns the router with all middlewares, to be served by an
+// httptest.Server.
+func (a *API) Handler() http.Handler {
+	return a.app.Handler()
+}
+
+// Close closes the connection to UsersService.
+func (a *API) Close() error {
+	return a.app.Shutdown(context.Background())
+}
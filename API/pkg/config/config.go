@@ -14,11 +14,106 @@ type Config struct {
 	ExpirationTime time.Duration `yaml:"expiration_time"`
 	ServerHost     string        `yaml:"userserver_host"`
 	ServerPort     int           `yaml:"userserver_port"`
+	UsersService   ClientConfig  `yaml:"userserver"`
+	Auth           AuthConfig    `yaml:"auth"`
+	Tracing        TracingConfig `yaml:"tracing"`
+}
+
+type TracingConfig struct {
+	// Exporter is one of "none", "stdout" or "otlp".
+	Exporter string `yaml:"exporter" env-default:"none"`
+	// Endpoint is the address of the OTLP gRPC collector.
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure" env-default:"true"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"api"`
+}
+
+// ClientConfig configures the connection to UsersService shared by all requests.
+type ClientConfig struct {
+	// Addresses is a static list of "host:port" to balance across. When it's
+	// empty userserver_host is resolved through DNS and requests are balanced
+	// across all returned addresses.
+	Addresses      []string             `yaml:"addresses"`
+	LoadBalancing  string               `yaml:"load_balancing" env-default:"round_robin"`
+	Keepalive      KeepaliveConfig      `yaml:"keepalive"`
+	Retry          RetryConfig          `yaml:"retry"`
+	Hedging        HedgingConfig        `yaml:"hedging"`
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit_breaker"`
+}
+
+// RetryConfig applies to idempotent RPCs only, see resilience.Idempotent.
+type RetryConfig struct {
+	// MaxAttempts includes the first attempt, 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts" env-default:"3"`
+	// PerAttemptTimeout bounds a single attempt, 0 leaves only the request deadline.
+	PerAttemptTimeout time.Duration `yaml:"per_attempt_timeout" env-default:"2s"`
+	InitialBackoff    time.Duration `yaml:"initial_backoff" env-default:"100ms"`
+	MaxBackoff        time.Duration `yaml:"max_backoff" env-default:"2s"`
+	Multiplier        float64       `yaml:"multiplier" env-default:"2"`
+}
+
+type HedgingConfig struct {
+	// Delay after which a second attempt of a slow idempotent RPC is sent
+	// alongside the first one, 0 disables hedging.
+	Delay time.Duration `yaml:"delay" env-default:"0s"`
+}
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the breaker.
+	FailureThreshold int `yaml:"failure_threshold" env-default:"5"`
+	// OpenTimeout is how long the breaker fails fast before letting probes through.
+	OpenTimeout time.Duration `yaml:"open_timeout" env-default:"30s"`
+	// HalfOpenRequests is the number of probes let through at once after OpenTimeout.
+	HalfOpenRequests int `yaml:"half_open_requests" env-default:"1"`
+}
+
+type KeepaliveConfig struct {
+	// Time is the idle time after which the client pings the server.
+	Time                time.Duration `yaml:"time" env-default:"1m"`
+	Timeout             time.Duration `yaml:"timeout" env-default:"20s"`
+	PermitWithoutStream bool          `yaml:"permit_without_stream" env-default:"true"`
 }
 
 type ApiConfig struct {
-	Port    int           `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Port int `yaml:"port"`
+	// Timeout bounds reading a request and writing its response. Users
+	// exports push their write deadline back as they go.
+	Timeout time.Duration `yaml:"timeout" env-default:"30s"`
+	// ReadHeaderTimeout bounds reading the request headers, so slow clients
+	// can't hold connections.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env-default:"5s"`
+	// IdleTimeout is how long a keep-alive connection waits for the next request.
+	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"2m"`
+	// ShutdownTimeout bounds draining in-flight requests on shutdown.
+	ShutdownTimeout time.Duration   `yaml:"shutdown_timeout" env-default:"15s"`
+	Readiness       ReadinessConfig `yaml:"readiness"`
+}
+
+type ReadinessConfig struct {
+	// Timeout bounds the check of a single dependency.
+	Timeout time.Duration `yaml:"timeout" env-default:"1s"`
+	// CacheTTL is how long a readiness result is reused by later probes.
+	CacheTTL time.Duration `yaml:"cache_ttl" env-default:"2s"`
+	// DrainDelay is how long readiness fails on shutdown before the listener
+	// closes, so load balancers notice and stop sending requests. It counts
+	// against ShutdownTimeout.
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+type AuthConfig struct {
+	Issuer string `yaml:"issuer" env-default:"users-service"`
+	// JWKSRefreshInterval is how long fetched signing keys are cached.
+	JWKSRefreshInterval time.Duration  `yaml:"jwks_refresh_interval" env-default:"5m"`
+	Leeway              time.Duration  `yaml:"leeway" env-default:"30s"`
+	APIKeys             []APIKeyConfig `yaml:"api_keys"`
+}
+
+// APIKeyConfig stores the hex encoded SHA-256 hash of a key, never the key itself.
+type APIKeyConfig struct {
+	Name  string   `yaml:"name"`
+	Hash  string   `yaml:"hash"`
+	Roles []string `yaml:"roles"`
 }
 
 func MustLoad() *Config {
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"users-service/internal/app"
-	"users-service/internal/storage/userstorage"
+	"users-service/internal/metrics"
+	"users-service/internal/storage/instrumented"
+	"users-service/internal/storage/migrator"
+	"users-service/internal/tokens"
+	"users-service/internal/tracing"
 	"users-service/pkg/config"
+	"users-service/pkg/hasher"
 	"users-service/pkg/logger"
+	"users-service/pkg/logger/sl"
 )
 
 func main() {
@@ -15,16 +24,63 @@ func main() {
 
 	log := logger.SetupLogger(config.Env)
 
+	if flag.NArg() > 0 {
+		if err := runCommand(log, config, flag.Args()); err != nil {
+			log.Error("command failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Info("Ready to change")
 
-	storage := userstorage.New(log, config.ConnStr)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.Tracing)
+	if err != nil {
+		panic(err)
+	}
+
+	passwordHasher, err := hasher.New(config.Hasher)
+	if err != nil {
+		panic(err)
+	}
+
+	tokenIssuer, err := newTokenIssuer(log, config)
+	if err != nil {
+		panic(err)
+	}
 
-	application := app.New(log, storage, config.Grpc.Port)
+	if config.Storage.AutoMigrate && usesSQL(config) {
+		if err := migrate(log, config, migrator.CommandUp); err != nil {
+			panic(err)
+		}
+	}
+
+	storages := newStorages(log, config)
+	if storages.db != nil {
+		metrics.RegisterDB(storages.db, "users")
+	}
+
+	application := app.New(
+		log,
+		instrumented.NewUserStorage(storages.users),
+		instrumented.NewSessionStorage(storages.sessions),
+		instrumented.NewRoleStorage(storages.roles),
+		storages.pinger,
+		passwordHasher,
+		tokenIssuer,
+		config.Tokens.RefreshTTL,
+		config.Tokens.SessionMaxLifetime,
+		config.Grpc,
+	)
 
 	go func() {
 		application.GRPCServer.MustRun()
 	}()
 
+	go func() {
+		application.AdminServer.MustRun()
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
@@ -32,9 +88,23 @@ func main() {
 
 	log.Info("Stoping application")
 	application.GRPCServer.Stop()
+	application.AdminServer.Stop()
 
 	log.Info("Stoping db")
-	storage.Close()
+	storages.close()
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error("cannot flush traces", sl.Err(err))
+	}
 
 	log.Info("application is stopped")
 }
+
+func newTokenIssuer(log *slog.Logger, cfg *config.Config) (*tokens.Issuer, error) {
+	if len(cfg.Tokens.Keys) == 0 && cfg.Env == config.EnvLocal {
+		log.Warn("no token signing keys configured, using an ephemeral key")
+		return tokens.NewEphemeral(cfg.Tokens.Issuer, cfg.ExpirationTime)
+	}
+
+	return tokens.New(cfg.Tokens, cfg.ExpirationTime)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"users-service/internal/storage/dbconn"
+	"users-service/internal/storage/migrator"
+	"users-service/internal/storage/sqlitestorage"
+	"users-service/pkg/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+)
+
+// runCommand runs the subcommand given after the flags, e.g.
+//
+//	cli --config=./config/local.yaml migrate up
+func runCommand(log *slog.Logger, cfg *config.Config, args []string) error {
+	if len(args) != 2 || args[0] != "migrate" {
+		return fmt.Errorf("unknown command %q, usage: cli --config=<path> migrate <%s>",
+			strings.Join(args, " "), strings.Join(migrator.Commands, "|"))
+	}
+
+	if !usesSQL(cfg) {
+		return fmt.Errorf("driver %s has no schema to migrate", cfg.Storage.Driver)
+	}
+
+	return migrate(log, cfg, args[1])
+}
+
+// migrate runs a migrator command over a connection of its own, closed
+// before the storages connect: the pgx storage prepares its statements on
+// connect, so the schema must be in place by then.
+func migrate(log *slog.Logger, cfg *config.Config, command string) error {
+	const op = "main.migrate"
+
+	var (
+		db      *sql.DB
+		dialect = migrator.DialectPostgres
+		err     error
+	)
+	if cfg.Storage.Driver == config.DriverSQLite {
+		dialect = migrator.DialectSQLite
+		db, err = sqlitestorage.Open(cfg.Storage.SQLite.Path)
+	} else {
+		db, err = sql.Open("pgx", cfg.ConnStr)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer db.Close()
+
+	if err := dbconn.Wait(context.Background(), log, db.PingContext, cfg.Storage); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrator.New(log, db, dialect)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return m.Run(context.Background(), command)
+}
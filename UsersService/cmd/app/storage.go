@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/storage/memstorage"
+	"users-service/internal/storage/pgxstorage"
+	"users-service/internal/storage/rolestorage"
+	"users-service/internal/storage/sessionstorage"
+	"users-service/internal/storage/sqlitestorage"
+	"users-service/internal/storage/userstorage"
+	"users-service/pkg/config"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// storages are the storages of the driver selected by config.Storage.Driver.
+type storages struct {
+	users    storage.IUserStorage
+	sessions storage.ISessionStorage
+	roles    storage.IRoleStorage
+	// pinger backs the health checks.
+	pinger storage.IPinger
+	// db is the connection pool of the SQL drivers, nil otherwise.
+	db    *sql.DB
+	close func()
+}
+
+func newStorages(log *slog.Logger, cfg *config.Config) storages {
+	switch cfg.Storage.Driver {
+	case config.DriverPostgres:
+		users := userstorage.New(log, cfg.ConnStr, cfg.Storage)
+
+		return storages{
+			users:    users,
+			sessions: sessionstorage.New(log, users.DB),
+			roles:    rolestorage.New(log, users.DB),
+			pinger:   users.DB,
+			db:       users.DB,
+			close:    users.Close,
+		}
+	case config.DriverPgx:
+		users := pgxstorage.New(log, cfg.ConnStr, cfg.Storage)
+		// Sessions and roles run on database/sql over the same pool.
+		db := stdlib.OpenDBFromPool(users.Pool)
+
+		return storages{
+			users:    users,
+			sessions: sessionstorage.New(log, db),
+			roles:    rolestorage.New(log, db),
+			pinger:   db,
+			db:       db,
+			close: func() {
+				db.Close()
+				users.Close()
+			},
+		}
+	case config.DriverSQLite:
+		users := sqlitestorage.New(log, cfg.Storage)
+
+		return storages{
+			users:    users,
+			sessions: sessionstorage.New(log, users.DB),
+			roles:    rolestorage.New(log, users.DB),
+			pinger:   users.DB,
+			db:       users.DB,
+			close:    users.Close,
+		}
+	case config.DriverMemory:
+		store, err := memstorage.New(log, cfg.Storage.Memory)
+		if err != nil {
+			panic(err)
+		}
+
+		return storages{
+			users:    store,
+			sessions: store,
+			roles:    store,
+			pinger:   store,
+			close:    store.Close,
+		}
+	default:
+		panic("unknown storage driver: " + cfg.Storage.Driver)
+	}
+}
+
+// usesSQL reports whether the selected driver keeps the data in a SQL
+// database, which has a schema to migrate.
+func usesSQL(cfg *config.Config) bool {
+	return cfg.Storage.Driver != config.DriverMemory
+}
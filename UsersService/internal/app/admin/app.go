@@ -0,0 +1,77 @@
+package adminapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"users-service/internal/metrics"
+	"users-service/pkg/logger/sl"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// shutdownTimeout bounds waiting for in-flight scrapes on Stop.
+const shutdownTimeout = 5 * time.Second
+
+// App serves operational endpoints, such as /metrics, on a port separate
+// from the gRPC one.
+type App struct {
+	log    *slog.Logger
+	server *http.Server
+	port   int
+}
+
+func New(log *slog.Logger, port int) *App {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	return &App{
+		log: log,
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		port: port,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "adminapp.Run"
+	log := a.log.With(
+		"op", op,
+	)
+
+	log.Info("starting admin server", slog.Int("port", a.port))
+
+	if err := a.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "adminapp.Stop"
+	log := a.log.With(
+		"op", op,
+	)
+
+	log.Info("stoping admin server", slog.Int("port", a.port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		log.Error("cannot stop admin server", sl.Err(err))
+	}
+}
@@ -2,21 +2,43 @@ package app
 
 import (
 	"log/slog"
+	"time"
+	adminapp "users-service/internal/app/admin"
 	grpcapp "users-service/internal/app/grpc"
+	"users-service/internal/domain/interfaces/hasher"
 	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/interfaces/tokens"
+	"users-service/internal/service/authservice"
+	"users-service/internal/service/roleservice"
 	"users-service/internal/service/userservice"
+	"users-service/pkg/config"
 )
 
 type App struct {
-	GRPCServer *grpcapp.App
+	GRPCServer  *grpcapp.App
+	AdminServer *adminapp.App
 }
 
-func New(log *slog.Logger, storage storage.IUserStorage, port int) *App {
-	userService := userservice.New(log, storage)
+func New(
+	log *slog.Logger,
+	storage storage.IUserStorage,
+	sessionStorage storage.ISessionStorage,
+	roleStorage storage.IRoleStorage,
+	db storage.IPinger,
+	hasher hasher.IPasswordHasher,
+	issuer tokens.ITokenIssuer,
+	refreshTTL time.Duration,
+	sessionMaxLifetime time.Duration,
+	grpcConfig config.GrpcConfig,
+) *App {
+	userService := userservice.New(log, storage, hasher)
+	authService := authservice.New(log, storage, sessionStorage, roleStorage, hasher, issuer, refreshTTL, sessionMaxLifetime)
+	roleService := roleservice.New(log, roleStorage)
 
-	grpcApp := grpcapp.New(log, userService, port)
+	grpcApp := grpcapp.New(log, userService, authService, roleService, db, grpcConfig)
 
 	return &App{
-		GRPCServer: grpcApp,
+		GRPCServer:  grpcApp,
+		AdminServer: adminapp.New(log, grpcConfig.AdminPort),
 	}
 }
@@ -4,27 +4,76 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"time"
 	"users-service/internal/domain/interfaces/service"
+	"users-service/internal/domain/interfaces/storage"
 	"users-service/internal/grpc/userservice"
+	"users-service/pkg/config"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 )
 
+// keepaliveMinTime is the shortest ping interval clients may use.
+const keepaliveMinTime = 30 * time.Second
+
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
+	health     *healthChecker
 	port       int
 }
 
-func New(log *slog.Logger, usersservice service.IUserService, port int) *App {
-	gRPCServer := grpc.NewServer()
+func New(
+	log *slog.Logger,
+	usersservice service.IUserService,
+	authservice service.IAuthService,
+	roleservice service.IRoleService,
+	db storage.IPinger,
+	cfg config.GrpcConfig,
+) *App {
+	gRPCServer := grpc.NewServer(
+		// Extracts the W3C trace context sent by the API and starts a span per call.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// Recovery is innermost, so a recovered panic is logged as Internal
+		// along with the request id.
+		grpc.ChainUnaryInterceptor(
+			requestIDUnary(),
+			loggingUnary(log),
+			metricsUnary(),
+			recoveryUnary(log),
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDStream(),
+			loggingStream(log),
+			metricsStream(),
+			recoveryStream(log),
+		),
+		// The API keeps long-lived connections and pings them while idle;
+		// the default policy would answer its pings with GOAWAY.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             keepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	)
+
+	userservice.Register(gRPCServer, usersservice, authservice, roleservice, log)
 
-	userservice.Register(gRPCServer, usersservice, log)
+	health := newHealthChecker(log, db, cfg.HealthCheckInterval)
+	healthpb.RegisterHealthServer(gRPCServer, health.server)
+
+	if cfg.Reflection {
+		reflection.Register(gRPCServer)
+	}
 
 	return &App{
 		log:        log,
 		gRPCServer: gRPCServer,
-		port:       port,
+		health:     health,
+		port:       cfg.Port,
 	}
 }
 
@@ -47,6 +96,20 @@ func (a *App) Run() error {
 
 	log.Info("starting gRPC server", slog.String("addr", l.Addr().String()))
 
+	if err := a.Serve(l); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Serve serves gRPC calls on l until Stop is called. Run listens on the
+// configured port, Serve lets in-process tests use an in-memory listener.
+func (a *App) Serve(l net.Listener) error {
+	const op = "grpcapp.Serve"
+
+	go a.health.run()
+
 	if err := a.gRPCServer.Serve(l); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -60,5 +123,8 @@ func (a *App) Stop() {
 	a.log.With("op", op).
 		Info("stoping gRPC server", slog.Int("port", a.port))
 
+	// Health checks fail from now on, so load balancers and probes stop
+	// sending new calls while the in-flight ones are drained.
+	a.health.stop()
 	a.gRPCServer.GracefulStop()
 }
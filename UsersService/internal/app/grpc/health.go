@@ -0,0 +1,93 @@
+package grpcapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/pkg/logger/sl"
+	umv1 "users-service/proto/gen"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// healthPingTimeout bounds a single database ping of the health checker.
+	healthPingTimeout = 2 * time.Second
+	// defaultHealthCheckInterval replaces a health_check_interval that isn't
+	// positive, which time.NewTicker would panic on.
+	defaultHealthCheckInterval = 5 * time.Second
+)
+
+// healthChecker keeps the status of the health service in line with the
+// database: both the server as a whole ("") and UsersService are SERVING
+// only while the database answers pings.
+type healthChecker struct {
+	log      *slog.Logger
+	server   *health.Server
+	db       storage.IPinger
+	interval time.Duration
+	done     chan struct{}
+}
+
+func newHealthChecker(log *slog.Logger, db storage.IPinger, interval time.Duration) *healthChecker {
+	server := health.NewServer()
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	server.SetServingStatus(umv1.UsersService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if interval <= 0 {
+		log.Warn("health check interval must be positive, using the default",
+			slog.Duration("interval", interval), slog.Duration("default", defaultHealthCheckInterval))
+		interval = defaultHealthCheckInterval
+	}
+
+	return &healthChecker{
+		log:      log,
+		server:   server,
+		db:       db,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// run pings the database every interval until stop is called.
+func (h *healthChecker) run() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	h.check()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.check()
+		}
+	}
+}
+
+func (h *healthChecker) check() {
+	const op = "grpcapp.healthChecker.check"
+	log := h.log.With(
+		"op", op,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := h.db.PingContext(ctx); err != nil {
+		log.Warn("database is unreachable", sl.Err(err))
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	h.server.SetServingStatus("", status)
+	h.server.SetServingStatus(umv1.UsersService_ServiceDesc.ServiceName, status)
+}
+
+// stop reports NOT_SERVING for good and stops pinging the database.
+func (h *healthChecker) stop() {
+	h.server.Shutdown()
+	close(h.done)
+}
@@ -0,0 +1,6 @@
+package hasher
+
+type IPasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+}
@@ -8,9 +8,26 @@ import (
 )
 
 type IUserService interface {
-	GetUsers(context.Context) ([]models.User, error)
+	GetUsers(context.Context, models.UsersQuery) (models.UsersPage, error)
+	StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error
 	GetUserById(context.Context, uuid.UUID) (models.User, error)
 	InsertUser(context.Context, models.User) (models.User, error)
-	UpdateUser(context.Context, uuid.UUID, models.User) (models.User, error)
+	UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error)
 	DeleteUser(context.Context, uuid.UUID) (models.User, error)
 }
+
+type IAuthService interface {
+	Authenticate(ctx context.Context, login, password string, client models.ClientInfo) (models.User, models.Tokens, error)
+	GetJWKS(context.Context) []models.JWK
+	RefreshSession(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error)
+	ListSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) (models.Session, error)
+	RevokeSessions(ctx context.Context, userId uuid.UUID) (int64, error)
+}
+
+type IRoleService interface {
+	CreateRole(context.Context, models.Role) (models.Role, error)
+	GrantRole(ctx context.Context, userId uuid.UUID, role string) ([]string, error)
+	RevokeRole(ctx context.Context, userId uuid.UUID, role string) ([]string, error)
+	CheckPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error)
+}
@@ -8,9 +8,40 @@ import (
 )
 
 type IUserStorage interface {
-	GetUsers(context.Context) ([]models.User, error)
+	GetUsers(context.Context, models.UsersFilter) ([]models.User, error)
+	StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error
 	GetUserById(context.Context, uuid.UUID) (models.User, error)
+	GetUserByLogin(context.Context, string) (models.User, error)
 	InsertUser(context.Context, models.User) (models.User, error)
-	UpdateUser(context.Context, uuid.UUID, models.User) (models.User, error)
+	// UpdateUser replaces the login and the password of the user. With
+	// keepLogin, the update only applies if the login is the stored one and
+	// fails with ErrLoginChanged otherwise.
+	UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error)
 	DeleteUser(context.Context, uuid.UUID) (models.User, error)
 }
+
+type ISessionStorage interface {
+	GetSession(context.Context, uuid.UUID) (models.Session, error)
+	GetActiveSessions(context.Context, uuid.UUID) ([]models.Session, error)
+	InsertSession(context.Context, models.Session) (models.Session, error)
+	UpdateSession(context.Context, models.Session) (models.Session, error)
+	RevokeSession(context.Context, uuid.UUID) error
+	RevokeUserSessions(context.Context, uuid.UUID) (int64, error)
+	GetRefreshToken(context.Context, string) (models.RefreshToken, error)
+	InsertRefreshToken(context.Context, models.RefreshToken) error
+	UseRefreshToken(context.Context, string) error
+}
+
+type IRoleStorage interface {
+	GetRoleByName(context.Context, string) (models.Role, error)
+	InsertRole(context.Context, models.Role) (models.Role, error)
+	GrantRole(ctx context.Context, userId, roleId uuid.UUID) error
+	RevokeRole(ctx context.Context, userId, roleId uuid.UUID) error
+	GetUserRoles(context.Context, uuid.UUID) ([]string, error)
+	HasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error)
+}
+
+// IPinger reports whether the underlying database is reachable.
+type IPinger interface {
+	PingContext(context.Context) error
+}
@@ -0,0 +1,12 @@
+package tokens
+
+import (
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type ITokenIssuer interface {
+	Issue(userId uuid.UUID, roles []string) (models.Tokens, error)
+	JWKS() []models.JWK
+}
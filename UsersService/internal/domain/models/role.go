@@ -0,0 +1,10 @@
+package models
+
+import "github.com/google/uuid"
+
+type Role struct {
+	Id          uuid.UUID `json:"id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Permissions []string  `json:"permissions,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a refresh token family: every refresh token issued for one
+// login belongs to the same session, so revoking the session revokes all of them.
+type Session struct {
+	Id         uuid.UUID `json:"id,omitempty"`
+	UserId     uuid.UUID `json:"user_id,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	RevokedAt  time.Time `json:"revoked_at,omitempty"`
+}
+
+// RefreshToken is stored by the SHA-256 hash of the opaque token value.
+type RefreshToken struct {
+	Hash      string
+	SessionId uuid.UUID
+	CreatedAt time.Time
+	UsedAt    time.Time
+}
+
+// ClientInfo describes the device a session was started from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
@@ -1,9 +1,66 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
 
 type User struct {
-	Id       uuid.UUID `json:"id,omitempty"`
-	Login    string    `json:"login,omitempty"`
-	Password string    `json:"password,omitempty"`
+	Id        uuid.UUID `json:"id,omitempty"`
+	Login     string    `json:"login,omitempty"`
+	Password  string    `json:"password,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// NormalizeLogin is the form logins are stored and looked up in, so logins
+// differing only by case, surrounding spaces or Unicode compatibility forms
+// (e.g. full-width letters) belong to the same user.
+func NormalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(login)))
+}
+
+type UserSort int
+
+const (
+	SortCreatedAtAsc UserSort = iota
+	SortCreatedAtDesc
+	SortLoginAsc
+	SortLoginDesc
+)
+
+// UsersCursor is the position after which the next page starts: the sort
+// key of the last user of the previous page and its id as a tie breaker.
+type UsersCursor struct {
+	CreatedAt time.Time
+	Login     string
+	Id        uuid.UUID
+}
+
+// UsersFilter selects a page of users. Zero values disable the corresponding
+// filter; CreatedAfter is inclusive, CreatedBefore is exclusive.
+type UsersFilter struct {
+	LoginPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          UserSort
+	After         *UsersCursor
+	Limit         int
+}
+
+// UsersQuery is a request of a page of users.
+type UsersQuery struct {
+	PageSize      int
+	PageToken     string
+	LoginPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Sort          UserSort
+}
+
+type UsersPage struct {
+	Users         []User
+	NextPageToken string
 }
@@ -0,0 +1,15 @@
+package profiles
+
+import (
+	"users-service/internal/domain/models"
+	umv1 "users-service/proto/gen"
+)
+
+func RoleToProtoRole(role models.Role) *umv1.Role {
+	return &umv1.Role{
+		Id:          role.Id.String(),
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	}
+}
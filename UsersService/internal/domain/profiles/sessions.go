@@ -0,0 +1,20 @@
+package profiles
+
+import (
+	"users-service/internal/domain/models"
+	umv1 "users-service/proto/gen"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func SessionToProtoSession(session models.Session) *umv1.Session {
+	return &umv1.Session{
+		Id:         session.Id.String(),
+		UserId:     session.UserId.String(),
+		UserAgent:  session.UserAgent,
+		Ip:         session.IP,
+		CreatedAt:  timestamppb.New(session.CreatedAt),
+		LastUsedAt: timestamppb.New(session.LastUsedAt),
+		ExpiresAt:  timestamppb.New(session.ExpiresAt),
+	}
+}
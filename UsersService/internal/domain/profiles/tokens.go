@@ -0,0 +1,29 @@
+package profiles
+
+import (
+	"time"
+	"users-service/internal/domain/models"
+	umv1 "users-service/proto/gen"
+)
+
+func TokensToProtoTokens(tokens models.Tokens) *umv1.Tokens {
+	return &umv1.Tokens{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokens.TokenType,
+		ExpiresIn:    int64(time.Until(tokens.ExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	}
+}
+
+func JWKToProtoJWK(jwk models.JWK) *umv1.JWK {
+	return &umv1.JWK{
+		Kty: jwk.Kty,
+		Kid: jwk.Kid,
+		Alg: jwk.Alg,
+		Use: jwk.Use,
+		Crv: jwk.Crv,
+		X:   jwk.X,
+		N:   jwk.N,
+		E:   jwk.E,
+	}
+}
@@ -1,18 +1,26 @@
 package profiles
 
 import (
+	"fmt"
 	"users-service/internal/domain/models"
 	umv1 "users-service/proto/gen"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// UserToProtoUser leaves the password out: the stored hash never leaves the service.
 func UserToProtoUser(user models.User) *umv1.User {
-	return &umv1.User{
-		Id:       user.Id.String(),
-		Login:    user.Login,
-		Password: user.Password,
+	res := &umv1.User{
+		Id:    user.Id.String(),
+		Login: user.Login,
+	}
+
+	if !user.CreatedAt.IsZero() {
+		res.CreatedAt = timestamppb.New(user.CreatedAt)
 	}
+
+	return res
 }
 
 func ProtoUserToUser(user *umv1.User) models.User {
@@ -24,3 +32,48 @@ func ProtoUserToUser(user *umv1.User) models.User {
 		Password: user.Password,
 	}
 }
+
+func ProtoGetUsersRequestToUsersQuery(req *umv1.GetUsersRequest) (models.UsersQuery, error) {
+	query := models.UsersQuery{
+		PageSize:    int(req.GetPageSize()),
+		PageToken:   req.GetPageToken(),
+		LoginPrefix: req.GetLoginPrefix(),
+	}
+
+	if req.GetCreatedAfter() != nil {
+		query.CreatedAfter = req.GetCreatedAfter().AsTime()
+	}
+	if req.GetCreatedBefore() != nil {
+		query.CreatedBefore = req.GetCreatedBefore().AsTime()
+	}
+
+	switch req.GetSort() {
+	case umv1.UserSortOrder_USER_SORT_ORDER_UNSPECIFIED, umv1.UserSortOrder_USER_SORT_ORDER_CREATED_AT_ASC:
+		query.Sort = models.SortCreatedAtAsc
+	case umv1.UserSortOrder_USER_SORT_ORDER_CREATED_AT_DESC:
+		query.Sort = models.SortCreatedAtDesc
+	case umv1.UserSortOrder_USER_SORT_ORDER_LOGIN_ASC:
+		query.Sort = models.SortLoginAsc
+	case umv1.UserSortOrder_USER_SORT_ORDER_LOGIN_DESC:
+		query.Sort = models.SortLoginDesc
+	default:
+		return models.UsersQuery{}, fmt.Errorf("unknown sort order %d", req.GetSort())
+	}
+
+	return query, nil
+}
+
+func ProtoStreamUsersRequestToUsersFilter(req *umv1.StreamUsersRequest) models.UsersFilter {
+	filter := models.UsersFilter{
+		LoginPrefix: req.GetLoginPrefix(),
+	}
+
+	if req.GetCreatedAfter() != nil {
+		filter.CreatedAfter = req.GetCreatedAfter().AsTime()
+	}
+	if req.GetCreatedBefore() != nil {
+		filter.CreatedBefore = req.GetCreatedBefore().AsTime()
+	}
+
+	return filter
+}
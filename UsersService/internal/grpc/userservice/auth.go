@@ -0,0 +1,85 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"users-service/internal/domain/models"
+	"users-service/internal/domain/profiles"
+	serviceerror "users-service/internal/service"
+	"users-service/internal/validation"
+	"users-service/pkg/logger/sl"
+	umv1 "users-service/proto/gen"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *serverAPI) Authenticate(ctx context.Context, req *umv1.AuthenticateRequest) (*umv1.AuthenticateResponse, error) {
+	const op = "grpc.userservice.Authenticate"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	// Only presence is checked: logins and passwords set before the current
+	// policy must still be able to sign in.
+	login, password := req.GetLogin(), req.GetPassword()
+	var v validation.Validator
+	v.Required(fieldLogin, login)
+	v.Required(fieldPassword, password)
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	user, tokens, err := s.authService.Authenticate(ctx, login, password, models.ClientInfo{
+		UserAgent: req.GetUserAgent(),
+		IP:        req.GetIp(),
+	})
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidCredentials) {
+			log.WarnContext(ctx, "invalid credentials", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid login or password")
+		}
+
+		log.ErrorContext(ctx, "cannot authenticate user", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot authenticate user")
+	}
+
+	return &umv1.AuthenticateResponse{
+		User:   profiles.UserToProtoUser(user),
+		Tokens: profiles.TokensToProtoTokens(tokens),
+	}, nil
+}
+
+func (s *serverAPI) GetJWKS(ctx context.Context, req *emptypb.Empty) (*umv1.GetJWKSResponse, error) {
+	const op = "grpc.userservice.GetJWKS"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	jwks := s.authService.GetJWKS(ctx)
+
+	keys := make([]*umv1.JWK, 0, len(jwks))
+	for _, jwk := range jwks {
+		keys = append(keys, profiles.JWKToProtoJWK(jwk))
+	}
+
+	return &umv1.GetJWKSResponse{
+		Keys: keys,
+	}, nil
+}
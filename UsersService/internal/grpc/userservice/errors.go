@@ -0,0 +1,65 @@
+package userservice
+
+import (
+	"errors"
+	"users-service/internal/validation"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Fields named in the details of errors.
+const (
+	fieldId            = "id"
+	fieldLogin         = "login"
+	fieldPassword      = "password"
+	fieldUserId        = "user_id"
+	fieldSessionId     = "session_id"
+	fieldRole          = "role"
+	fieldPermission    = "permission"
+	fieldPageSize      = "page_size"
+	fieldPageToken     = "page_token"
+	fieldSort          = "sort"
+	fieldCreatedAfter  = "created_after"
+	fieldCreatedBefore = "created_before"
+)
+
+// fieldError returns a status carrying a BadRequest detail that points at
+// the field, so clients can tell which input to fix.
+func fieldError(code codes.Code, field, description string) error {
+	return violationsError(code, description, validation.Violation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// invalidArgument returns an InvalidArgument status listing every violation
+// of a *validation.Error in a BadRequest detail.
+func invalidArgument(err error) error {
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return violationsError(codes.InvalidArgument, verr.Error(), verr.Violations...)
+}
+
+func violationsError(code codes.Code, message string, violations ...validation.Violation) error {
+	badRequest := &errdetails.BadRequest{
+		FieldViolations: make([]*errdetails.BadRequest_FieldViolation, 0, len(violations)),
+	}
+	for _, violation := range violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		})
+	}
+
+	st, err := status.New(code, message).WithDetails(badRequest)
+	if err != nil {
+		return status.Error(code, message)
+	}
+
+	return st.Err()
+}
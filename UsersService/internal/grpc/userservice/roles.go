@@ -0,0 +1,161 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"users-service/internal/domain/models"
+	"users-service/internal/domain/profiles"
+	serviceerror "users-service/internal/service"
+	"users-service/internal/validation"
+	"users-service/pkg/logger/sl"
+	umv1 "users-service/proto/gen"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *serverAPI) CreateRole(ctx context.Context, req *umv1.CreateRoleRequest) (*umv1.CreateRoleResponse, error) {
+	const op = "grpc.userservice.CreateRole"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	name := req.GetName()
+	if name == "" {
+		log.ErrorContext(ctx, "name is required", sl.Err(fmt.Errorf("%s: %s", op, "name is required")))
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	role, err := s.roleService.CreateRole(ctx, models.Role{
+		Name:        name,
+		Description: req.GetDescription(),
+		Permissions: req.GetPermissions(),
+	})
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrAlreadyExists) {
+			log.WarnContext(ctx, "role already exists", sl.Err(err))
+			return nil, status.Error(codes.AlreadyExists, "role already exists")
+		}
+
+		log.ErrorContext(ctx, "cannot create role", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot create role")
+	}
+
+	return &umv1.CreateRoleResponse{
+		Role: profiles.RoleToProtoRole(role),
+	}, nil
+}
+
+func (s *serverAPI) GrantRole(ctx context.Context, req *umv1.GrantRoleRequest) (*umv1.GrantRoleResponse, error) {
+	const op = "grpc.userservice.GrantRole"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	v.Required(fieldRole, req.GetRole())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	roles, err := s.roleService.GrantRole(ctx, userId, req.GetRole())
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrNotFound) {
+			log.WarnContext(ctx, "user or role doesn't exists", sl.Err(err))
+			return nil, status.Error(codes.NotFound, "user or role doesn't exists")
+		}
+
+		log.ErrorContext(ctx, "cannot grant role", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot grant role")
+	}
+
+	return &umv1.GrantRoleResponse{
+		Roles: roles,
+	}, nil
+}
+
+func (s *serverAPI) RevokeRole(ctx context.Context, req *umv1.RevokeRoleRequest) (*umv1.RevokeRoleResponse, error) {
+	const op = "grpc.userservice.RevokeRole"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	v.Required(fieldRole, req.GetRole())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	roles, err := s.roleService.RevokeRole(ctx, userId, req.GetRole())
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrNotFound) {
+			log.WarnContext(ctx, "role isn't granted", sl.Err(err))
+			return nil, status.Error(codes.NotFound, "role isn't granted")
+		}
+
+		log.ErrorContext(ctx, "cannot revoke role", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot revoke role")
+	}
+
+	return &umv1.RevokeRoleResponse{
+		Roles: roles,
+	}, nil
+}
+
+func (s *serverAPI) CheckPermission(ctx context.Context, req *umv1.CheckPermissionRequest) (*umv1.CheckPermissionResponse, error) {
+	const op = "grpc.userservice.CheckPermission"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	v.Required(fieldPermission, req.GetPermission())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	allowed, err := s.roleService.CheckPermission(ctx, userId, req.GetPermission())
+	if err != nil {
+		log.ErrorContext(ctx, "cannot check permission", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot check permission")
+	}
+
+	return &umv1.CheckPermissionResponse{
+		Allowed: allowed,
+	}, nil
+}
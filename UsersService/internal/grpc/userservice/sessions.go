@@ -0,0 +1,158 @@
+package userservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"users-service/internal/domain/models"
+	"users-service/internal/domain/profiles"
+	serviceerror "users-service/internal/service"
+	"users-service/internal/validation"
+	"users-service/pkg/logger/sl"
+	umv1 "users-service/proto/gen"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *serverAPI) RefreshSession(ctx context.Context, req *umv1.RefreshSessionRequest) (*umv1.RefreshSessionResponse, error) {
+	const op = "grpc.userservice.RefreshSession"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	refreshToken := req.GetRefreshToken()
+	if refreshToken == "" {
+		log.ErrorContext(ctx, "refresh token is required", sl.Err(fmt.Errorf("%s: %s", op, "refresh token is required")))
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	tokens, err := s.authService.RefreshSession(ctx, refreshToken, models.ClientInfo{
+		UserAgent: req.GetUserAgent(),
+		IP:        req.GetIp(),
+	})
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidRefreshToken) || errors.Is(err, serviceerror.ErrRefreshTokenReused) {
+			log.WarnContext(ctx, "invalid refresh token", sl.Err(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+		}
+
+		log.ErrorContext(ctx, "cannot refresh session", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot refresh session")
+	}
+
+	return &umv1.RefreshSessionResponse{
+		Tokens: profiles.TokensToProtoTokens(tokens),
+	}, nil
+}
+
+func (s *serverAPI) ListSessions(ctx context.Context, req *umv1.ListSessionsRequest) (*umv1.ListSessionsResponse, error) {
+	const op = "grpc.userservice.ListSessions"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	sessions, err := s.authService.ListSessions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch sessions", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot fetch sessions")
+	}
+
+	var resSessions = make([]*umv1.Session, 0, len(sessions))
+	for _, session := range sessions {
+		resSessions = append(resSessions, profiles.SessionToProtoSession(session))
+	}
+
+	return &umv1.ListSessionsResponse{
+		Sessions: resSessions,
+	}, nil
+}
+
+func (s *serverAPI) RevokeSession(ctx context.Context, req *umv1.RevokeSessionRequest) (*umv1.RevokeSessionResponse, error) {
+	const op = "grpc.userservice.RevokeSession"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	sessionId := v.Id(fieldSessionId, req.GetSessionId())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	session, err := s.authService.RevokeSession(ctx, userId, sessionId)
+	if err != nil {
+		if errors.Is(err, serviceerror.ErrNotFound) {
+			log.WarnContext(ctx, "session doesn't exists", sl.Err(err))
+			return nil, status.Error(codes.NotFound, "session doesn't exists")
+		}
+
+		log.ErrorContext(ctx, "cannot revoke session", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot revoke session")
+	}
+
+	return &umv1.RevokeSessionResponse{
+		Session: profiles.SessionToProtoSession(session),
+	}, nil
+}
+
+func (s *serverAPI) RevokeSessions(ctx context.Context, req *umv1.RevokeSessionsRequest) (*umv1.RevokeSessionsResponse, error) {
+	const op = "grpc.userservice.RevokeSessions"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	userId := v.Id(fieldUserId, req.GetUserId())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	revoked, err := s.authService.RevokeSessions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot revoke sessions", sl.Err(err))
+		return nil, status.Error(codes.Internal, "cannot revoke sessions")
+	}
+
+	return &umv1.RevokeSessionsResponse{
+		Revoked: revoked,
+	}, nil
+}
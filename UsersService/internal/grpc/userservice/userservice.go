@@ -6,98 +6,174 @@ import (
 	"fmt"
 	"log/slog"
 	"users-service/internal/domain/interfaces/service"
+	"users-service/internal/domain/models"
 	"users-service/internal/domain/profiles"
 	serviceerror "users-service/internal/service"
+	"users-service/internal/validation"
 	"users-service/pkg/logger/sl"
 	umv1 "users-service/proto/gen"
 
-	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type serverAPI struct {
 	log         *slog.Logger
 	userService service.IUserService
+	authService service.IAuthService
+	roleService service.IRoleService
 	umv1.UnimplementedUsersServiceServer
 }
 
-func Register(grpc *grpc.Server, userService service.IUserService, log *slog.Logger) {
+func Register(
+	grpc *grpc.Server,
+	userService service.IUserService,
+	authService service.IAuthService,
+	roleService service.IRoleService,
+	log *slog.Logger,
+) {
 	umv1.RegisterUsersServiceServer(grpc, &serverAPI{
 		userService: userService,
+		authService: authService,
+		roleService: roleService,
 		log:         log,
 	})
 }
 
-func (s *serverAPI) GetUsers(ctx context.Context, req *emptypb.Empty) (*umv1.GetUsersResponse, error) {
-	const op = "grpc.userservice.GetUser"
+func (s *serverAPI) GetUsers(ctx context.Context, req *umv1.GetUsersRequest) (*umv1.GetUsersResponse, error) {
+	const op = "grpc.userservice.GetUsers"
 	log := s.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return nil, status.Error(codes.DeadlineExceeded, "request time out")
 	default:
 	}
 
-	users, err := s.userService.GetUsers(ctx)
+	var v validation.Validator
+	if req.GetPageSize() < 0 {
+		v.Add(fieldPageSize, "must not be negative")
+	}
+
+	query, err := profiles.ProtoGetUsersRequestToUsersQuery(req)
+	if err != nil {
+		v.Add(fieldSort, "unknown sort order")
+	}
+	v.TimeRange(fieldCreatedAfter, fieldCreatedBefore, query.CreatedAfter, query.CreatedBefore)
+
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid users query", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	page, err := s.userService.GetUsers(ctx, query)
 	if err != nil {
+		if errors.Is(err, serviceerror.ErrInvalidPageToken) {
+			log.WarnContext(ctx, "invalid page token", sl.Err(err))
+			return nil, fieldError(codes.InvalidArgument, fieldPageToken, "invalid page token")
+		}
+
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("users not found", sl.Err(err))
+			log.WarnContext(ctx, "users not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "users not found")
 		}
 
-		log.Error("Cannot fetch users", sl.Err(err))
+		log.ErrorContext(ctx, "Cannot fetch users", sl.Err(err))
 		return nil, status.Error(codes.Internal, "cannot fetch users")
 	}
 
-	var resUsers = make([]*umv1.User, 0, 5)
-	for _, user := range users {
+	var resUsers = make([]*umv1.User, 0, len(page.Users))
+	for _, user := range page.Users {
 		resUsers = append(resUsers, profiles.UserToProtoUser(user))
 	}
 
 	return &umv1.GetUsersResponse{
-		Users: resUsers,
+		Users:         resUsers,
+		NextPageToken: page.NextPageToken,
 	}, nil
 }
 
-func (s *serverAPI) GetUserById(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
-	const op = "grpc.userservice.GetUserById"
+// StreamUsers sends users one by one. Send blocks while the client's flow
+// control window is full, which in turn pauses reading rows from the database.
+func (s *serverAPI) StreamUsers(req *umv1.StreamUsersRequest, stream grpc.ServerStreamingServer[umv1.User]) error {
+	const op = "grpc.userservice.StreamUsers"
 	log := s.log.With(
-		op, "op",
+		"op", op,
 	)
 
+	ctx := stream.Context()
+
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
-		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return status.Error(codes.DeadlineExceeded, "request time out")
 	default:
 	}
 
-	reqId := req.GetId()
-	if reqId == "" {
-		log.Error("id is required", sl.Err(fmt.Errorf("%s: %s", op, "id is required")))
-		return nil, status.Error(codes.InvalidArgument, "id is required")
+	filter := profiles.ProtoStreamUsersRequestToUsersFilter(req)
+
+	var v validation.Validator
+	v.TimeRange(fieldCreatedAfter, fieldCreatedBefore, filter.CreatedAfter, filter.CreatedBefore)
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid users query", sl.Err(err))
+		return invalidArgument(err)
 	}
 
-	id, err := uuid.Parse(reqId)
+	var sent int
+	err := s.userService.StreamUsers(ctx, filter, func(user models.User) error {
+		if err := stream.Send(profiles.UserToProtoUser(user)); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
 	if err != nil {
-		log.Error("wrong id, must be uuid", sl.Err(fmt.Errorf("%s: %s", op, "wrong id, must be uuid")))
-		return nil, status.Error(codes.Internal, "wrong id, must be uuid")
+		if ctx.Err() != nil {
+			log.WarnContext(ctx, "stream aborted", slog.Int("sent", sent), sl.Err(err))
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		log.ErrorContext(ctx, "cannot stream users", slog.Int("sent", sent), sl.Err(err))
+		return status.Error(codes.Internal, "cannot stream users")
+	}
+
+	log.InfoContext(ctx, "users streamed", slog.Int("sent", sent))
+
+	return nil
+}
+
+func (s *serverAPI) GetUserById(ctx context.Context, req *umv1.GetUserByIdRequest) (*umv1.GetUserByIdResponse, error) {
+	const op = "grpc.userservice.GetUserById"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, status.Error(codes.DeadlineExceeded, "request time out")
+	default:
+	}
+
+	var v validation.Validator
+	id := v.Id(fieldId, req.GetId())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
 	}
 
 	user, err := s.userService.GetUserById(ctx, id)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "user doesn't exists")
 		}
 
-		log.Error("cannot fetch user by id", sl.Err(err))
+		log.ErrorContext(ctx, "cannot fetch user by id", sl.Err(err))
 		return nil, status.Error(codes.Internal, "cannot fetch user by id")
 	}
 
@@ -109,30 +185,44 @@ func (s *serverAPI) GetUserById(ctx context.Context, req *umv1.GetUserByIdReques
 func (s *serverAPI) InsertUser(ctx context.Context, req *umv1.InsertRequest) (*umv1.InsertResponse, error) {
 	const op = "grpc.userservice.insert"
 	log := s.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return nil, status.Error(codes.DeadlineExceeded, "request time out")
 	default:
 	}
 
 	reqUser := req.GetUser()
 	if reqUser == nil {
-		log.Error("user is required", sl.Err(fmt.Errorf("%s: %s", op, "user is required")))
+		log.ErrorContext(ctx, "user is required", sl.Err(fmt.Errorf("%s: %s", op, "user is required")))
 		return nil, status.Error(codes.InvalidArgument, "user is required")
 	}
 
+	var v validation.Validator
+	v.Id(fieldId, reqUser.GetId())
+	v.Login(fieldLogin, reqUser.GetLogin())
+	v.Password(fieldPassword, reqUser.GetPassword())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
 	user, err := s.userService.InsertUser(ctx, profiles.ProtoUserToUser(reqUser))
 	if err != nil {
+		if errors.Is(err, serviceerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return nil, fieldError(codes.AlreadyExists, fieldLogin, "login is taken")
+		}
+
 		if errors.Is(err, serviceerror.ErrAlreadyExists) {
-			log.Warn("user already exists", sl.Err(err))
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
 
-		log.Error("cannot insert user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
 		return nil, status.Error(codes.Internal, "cannot insert user")
 	}
 
@@ -149,37 +239,48 @@ func (s *serverAPI) UpdateUser(ctx context.Context, req *umv1.UpdateRequest) (*u
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return nil, status.Error(codes.DeadlineExceeded, "request time out")
 	default:
 	}
 
-	reqId := req.GetId()
-	if reqId == "" {
-		log.Error("id is required", sl.Err(fmt.Errorf("%s: %s", op, "id is required")))
-		return nil, status.Error(codes.InvalidArgument, "id is required")
-	}
-
-	id, err := uuid.Parse(reqId)
-	if err != nil {
-		log.Error("wrong id, must be uuid", sl.Err(fmt.Errorf("%s: %s", op, "wrong id, must be uuid")))
-		return nil, status.Error(codes.Internal, "wrong id, must be uuid")
-	}
-
 	req_user := req.GetUser()
 	if req_user == nil {
-		log.Error("user is required", sl.Err(fmt.Errorf("%s: %s", op, "user is required")))
+		log.ErrorContext(ctx, "user is required", sl.Err(fmt.Errorf("%s: %s", op, "user is required")))
 		return nil, status.Error(codes.InvalidArgument, "user is required")
 	}
 
-	user, err := s.userService.UpdateUser(ctx, id, profiles.ProtoUserToUser(req_user))
+	var v validation.Validator
+	id := v.Id(fieldId, req.GetId())
+	keepLogin := v.LoginChange(fieldLogin, req_user.GetLogin())
+	v.Password(fieldPassword, req_user.GetPassword())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
+	}
+
+	user, err := s.userService.UpdateUser(ctx, id, profiles.ProtoUserToUser(req_user), keepLogin)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(ctx, "user not found", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "user not found")
 		}
 
-		log.Error("cannot update user", sl.Err(err))
+		if errors.Is(err, serviceerror.ErrLoginChanged) {
+			// The login failing the policy isn't the user's current one.
+			var policy validation.Validator
+			policy.Login(fieldLogin, req_user.GetLogin())
+
+			log.WarnContext(ctx, "invalid request", sl.Err(policy.Err()))
+			return nil, invalidArgument(policy.Err())
+		}
+
+		if errors.Is(err, serviceerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return nil, fieldError(codes.AlreadyExists, fieldLogin, "login is taken")
+		}
+
+		log.ErrorContext(ctx, "cannot update user", sl.Err(err))
 		return nil, status.Error(codes.Internal, "cannot update user")
 	}
 
@@ -191,36 +292,31 @@ func (s *serverAPI) UpdateUser(ctx context.Context, req *umv1.UpdateRequest) (*u
 func (s *serverAPI) DeleteUser(ctx context.Context, req *umv1.DeleteResuest) (*umv1.DeleteResponse, error) {
 	const op = "grpc.userservice.delete"
 	log := s.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return nil, status.Error(codes.DeadlineExceeded, "request time out")
 	default:
 	}
 
-	reqId := req.GetId()
-	if reqId == "" {
-		log.Error("id is required", sl.Err(fmt.Errorf("%s: %s", op, "id is required")))
-		return nil, status.Error(codes.InvalidArgument, "id is required")
-	}
-
-	id, err := uuid.Parse(reqId)
-	if err != nil {
-		log.Error("wrong id, must be uuid", sl.Err(fmt.Errorf("%s: %s", op, "wrong id, must be uuid")))
-		return nil, status.Error(codes.Internal, "wrong id, must be uuid")
+	var v validation.Validator
+	id := v.Id(fieldId, req.GetId())
+	if err := v.Err(); err != nil {
+		log.ErrorContext(ctx, "invalid request", sl.Err(err))
+		return nil, invalidArgument(err)
 	}
 
 	user, err := s.userService.DeleteUser(ctx, id)
 	if err != nil {
 		if errors.Is(err, serviceerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return nil, status.Error(codes.NotFound, "user doesn't exists")
 		}
 
-		log.Error("cannot delete user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
 		return nil, status.Error(codes.Internal, "cannot delete user")
 	}
 
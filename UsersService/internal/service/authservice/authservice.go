@@ -0,0 +1,111 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"users-service/internal/domain/interfaces/hasher"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/interfaces/tokens"
+	"users-service/internal/domain/models"
+	serviceerror "users-service/internal/service"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+)
+
+type AuthService struct {
+	log            *slog.Logger
+	storage        storage.IUserStorage
+	sessionStorage storage.ISessionStorage
+	roleStorage    storage.IRoleStorage
+	hasher         hasher.IPasswordHasher
+	issuer         tokens.ITokenIssuer
+	refreshTTL     time.Duration
+	// sessionMaxLifetime caps the expiry of a session however often it is
+	// refreshed, counted from its creation.
+	sessionMaxLifetime time.Duration
+
+	// dummyHash is compared against when the login doesn't exist, so
+	// unknown logins take as long to reject as wrong passwords.
+	dummyHash string
+}
+
+func New(
+	log *slog.Logger,
+	storage storage.IUserStorage,
+	sessionStorage storage.ISessionStorage,
+	roleStorage storage.IRoleStorage,
+	hasher hasher.IPasswordHasher,
+	issuer tokens.ITokenIssuer,
+	refreshTTL time.Duration,
+	sessionMaxLifetime time.Duration,
+) *AuthService {
+	const op = "service.auth.New"
+
+	dummyHash, err := hasher.Hash("dummy password")
+	if err != nil {
+		log.With("op", op).Error("cannot hash dummy password", sl.Err(err))
+		panic(err)
+	}
+
+	return &AuthService{
+		log:                log,
+		storage:            storage,
+		sessionStorage:     sessionStorage,
+		roleStorage:        roleStorage,
+		hasher:             hasher,
+		issuer:             issuer,
+		refreshTTL:         refreshTTL,
+		sessionMaxLifetime: sessionMaxLifetime,
+		dummyHash:          dummyHash,
+	}
+}
+
+// Authenticate implements service.IAuthService.
+func (a *AuthService) Authenticate(ctx context.Context, login string, password string, client models.ClientInfo) (models.User, models.Tokens, error) {
+	const op = "service.auth.Authenticate"
+	log := a.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	// Logins are stored normalized, see UserService.InsertUser.
+	user, err := a.storage.GetUserByLogin(ctx, models.NormalizeLogin(login))
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			_ = a.hasher.Compare(a.dummyHash, password)
+
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
+			return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidCredentials)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch user by login", sl.Err(err))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.hasher.Compare(user.Password, password); err != nil {
+		log.WarnContext(ctx, "wrong password", sl.Err(err))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidCredentials)
+	}
+
+	tokens, err := a.startSession(ctx, user.Id, client)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot start session", sl.Err(err))
+		return models.User{}, models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, tokens, nil
+}
+
+// GetJWKS implements service.IAuthService.
+func (a *AuthService) GetJWKS(ctx context.Context) []models.JWK {
+	return a.issuer.JWKS()
+}
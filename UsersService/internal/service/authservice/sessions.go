@@ -0,0 +1,294 @@
+package authservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"users-service/internal/domain/models"
+	serviceerror "users-service/internal/service"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// RefreshSession implements service.IAuthService. The presented refresh token
+// is exchanged for a new one; presenting an already exchanged token means it
+// has leaked, so the whole session is revoked.
+func (a *AuthService) RefreshSession(ctx context.Context, refreshToken string, client models.ClientInfo) (models.Tokens, error) {
+	const op = "service.auth.RefreshSession"
+	log := a.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	hash := hashRefreshToken(refreshToken)
+
+	token, err := a.sessionStorage.GetRefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "refresh token not found", sl.Err(err))
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidRefreshToken)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch refresh token", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	session, err := a.sessionStorage.GetSession(ctx, token.SessionId)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "session not found", sl.Err(err))
+			return models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidRefreshToken)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch session", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UTC()
+	if !session.RevokedAt.IsZero() || now.After(session.ExpiresAt) {
+		log.WarnContext(ctx, "session is revoked or expired", slog.String("session_id", session.Id.String()))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidRefreshToken)
+	}
+
+	if !token.UsedAt.IsZero() {
+		return models.Tokens{}, a.revokeReusedSession(ctx, op, session)
+	}
+
+	if err := a.sessionStorage.UseRefreshToken(ctx, hash); err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			// Lost the race against a concurrent refresh with the same token.
+			return models.Tokens{}, a.revokeReusedSession(ctx, op, session)
+		}
+
+		log.ErrorContext(ctx, "cannot use refresh token", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	session.UserAgent = client.UserAgent
+	session.IP = client.IP
+	session.LastUsedAt = now
+	session.ExpiresAt = a.sessionExpiry(session.CreatedAt, now)
+
+	if _, err := a.sessionStorage.UpdateSession(ctx, session); err != nil {
+		log.ErrorContext(ctx, "cannot update session", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, session)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot issue tokens", sl.Err(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// ListSessions implements service.IAuthService.
+func (a *AuthService) ListSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	const op = "service.auth.ListSessions"
+	log := a.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	sessions, err := a.sessionStorage.GetActiveSessions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession implements service.IAuthService. Only refreshing is
+// stopped: access tokens already issued for the session stay valid until
+// they expire, at most the access token TTL later.
+func (a *AuthService) RevokeSession(ctx context.Context, userId, sessionId uuid.UUID) (models.Session, error) {
+	const op = "service.auth.RevokeSession"
+	log := a.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	session, err := a.sessionStorage.GetSession(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "session not found", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Sessions of other users are reported as missing to not disclose them.
+	if session.UserId != userId || !session.RevokedAt.IsZero() {
+		log.WarnContext(ctx, "session not found", slog.String("session_id", sessionId.String()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+	}
+
+	if err := a.sessionStorage.RevokeSession(ctx, sessionId); err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "session not found", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot revoke session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+	session.RevokedAt = time.Now().UTC()
+
+	return session, nil
+}
+
+// RevokeSessions implements service.IAuthService. As with RevokeSession,
+// access tokens already issued stay valid until they expire.
+func (a *AuthService) RevokeSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	const op = "service.auth.RevokeSessions"
+	log := a.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	revoked, err := a.sessionStorage.RevokeUserSessions(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot revoke sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+func (a *AuthService) startSession(ctx context.Context, userId uuid.UUID, client models.ClientInfo) (models.Tokens, error) {
+	const op = "service.auth.startSession"
+
+	now := time.Now().UTC()
+	session, err := a.sessionStorage.InsertSession(ctx, models.Session{
+		Id:         uuid.New(),
+		UserId:     userId,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  a.sessionExpiry(now, now),
+	})
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(ctx, session)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// issueTokens issues an access token carrying the user's current roles and
+// a new refresh token of the session.
+func (a *AuthService) issueTokens(ctx context.Context, session models.Session) (models.Tokens, error) {
+	const op = "service.auth.issueTokens"
+
+	roles, err := a.roleStorage.GetUserRoles(ctx, session.UserId)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = a.sessionStorage.InsertRefreshToken(ctx, models.RefreshToken{
+		Hash:      hashRefreshToken(refreshToken),
+		SessionId: session.Id,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issuer.Issue(session.UserId, roles)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+	tokens.RefreshToken = refreshToken
+
+	return tokens, nil
+}
+
+func (a *AuthService) revokeReusedSession(ctx context.Context, op string, session models.Session) error {
+	log := a.log.With(
+		"op", op,
+	)
+
+	log.WarnContext(ctx, "refresh token reuse detected, revoking session",
+		slog.String("session_id", session.Id.String()),
+		slog.String("user_id", session.UserId.String()),
+	)
+
+	if err := a.sessionStorage.RevokeSession(ctx, session.Id); err != nil && !errors.Is(err, storageerror.ErrNotFound) {
+		log.ErrorContext(ctx, "cannot revoke session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, serviceerror.ErrRefreshTokenReused)
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionExpiry slides the expiry of a session created at createdAt by the
+// refresh TTL from now, but never past its max lifetime: a leaked refresh
+// token that keeps being used still dies eventually.
+func (a *AuthService) sessionExpiry(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(a.refreshTTL)
+	if limit := createdAt.Add(a.sessionMaxLifetime); expiresAt.After(limit) {
+		return limit
+	}
+
+	return expiresAt
+}
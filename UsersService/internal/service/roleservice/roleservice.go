@@ -0,0 +1,168 @@
+package roleservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/models"
+	serviceerror "users-service/internal/service"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+type RoleService struct {
+	log     *slog.Logger
+	storage storage.IRoleStorage
+}
+
+func New(log *slog.Logger, storage storage.IRoleStorage) *RoleService {
+	return &RoleService{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// CreateRole implements service.IRoleService.
+func (r *RoleService) CreateRole(ctx context.Context, role models.Role) (models.Role, error) {
+	const op = "service.role.CreateRole"
+	log := r.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Role{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	role.Id = uuid.New()
+
+	role, err := r.storage.InsertRole(ctx, role)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrAlreadyExists) {
+			log.WarnContext(ctx, "role already exists", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, serviceerror.ErrAlreadyExists)
+		}
+
+		log.ErrorContext(ctx, "cannot insert role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+// GrantRole implements service.IRoleService. It returns the roles the user has afterwards.
+func (r *RoleService) GrantRole(ctx context.Context, userId uuid.UUID, roleName string) ([]string, error) {
+	const op = "service.role.GrantRole"
+	log := r.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	role, err := r.storage.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "role doesn't exists", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch role", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.storage.GrantRole(ctx, userId, role.Id); err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot grant role", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := r.storage.GetUserRoles(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch user roles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// RevokeRole implements service.IRoleService. It returns the roles the user has afterwards.
+func (r *RoleService) RevokeRole(ctx context.Context, userId uuid.UUID, roleName string) ([]string, error) {
+	const op = "service.role.RevokeRole"
+	log := r.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	role, err := r.storage.GetRoleByName(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "role doesn't exists", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot fetch role", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.storage.RevokeRole(ctx, userId, role.Id); err != nil {
+		if errors.Is(err, storageerror.ErrNotFound) {
+			log.WarnContext(ctx, "role isn't granted", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot revoke role", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := r.storage.GetUserRoles(ctx, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch user roles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// CheckPermission implements service.IRoleService.
+func (r *RoleService) CheckPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	const op = "service.role.CheckPermission"
+	log := r.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	allowed, err := r.storage.HasPermission(ctx, userId, permission)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot check permission", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, nil
+}
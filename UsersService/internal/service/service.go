@@ -1,8 +1,22 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrLoginTaken is the ErrAlreadyExists of a user whose login belongs
+	// to another one.
+	ErrLoginTaken = fmt.Errorf("login is taken: %w", ErrAlreadyExists)
+	// ErrLoginChanged is returned by updates that must keep the login of
+	// the user when the login given isn't the stored one.
+	ErrLoginChanged = errors.New("login doesn't match the stored one")
+
+	ErrInvalidCredentials  = errors.New("invalid login or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrInvalidPageToken    = errors.New("invalid page token")
 )
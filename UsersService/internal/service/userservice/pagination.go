@@ -0,0 +1,58 @@
+package userservice
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+// pageToken is the opaque cursor handed out to clients. It remembers the
+// sort order so a token can't be replayed against a different ordering.
+type pageToken struct {
+	Sort      models.UserSort `json:"s"`
+	CreatedAt time.Time       `json:"c"`
+	Login     string          `json:"l"`
+	Id        uuid.UUID       `json:"i"`
+}
+
+func encodePageToken(sort models.UserSort, cursor models.UsersCursor) string {
+	data, _ := json.Marshal(pageToken{
+		Sort:      sort,
+		CreatedAt: cursor.CreatedAt,
+		Login:     cursor.Login,
+		Id:        cursor.Id,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token string, sort models.UserSort) (models.UsersCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return models.UsersCursor{}, err
+	}
+
+	var t pageToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return models.UsersCursor{}, err
+	}
+
+	if t.Sort != sort {
+		return models.UsersCursor{}, errors.New("page token was issued for another sort order")
+	}
+
+	return models.UsersCursor{
+		CreatedAt: t.CreatedAt,
+		Login:     t.Login,
+		Id:        t.Id,
+	}, nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
+	"users-service/internal/domain/interfaces/hasher"
 	"users-service/internal/domain/interfaces/storage"
 	"users-service/internal/domain/models"
 	serviceerror "users-service/internal/service"
@@ -17,53 +19,123 @@ import (
 type UserService struct {
 	log     *slog.Logger
 	storage storage.IUserStorage
+	hasher  hasher.IPasswordHasher
 }
 
-func New(log *slog.Logger, storage storage.IUserStorage) *UserService {
+func New(log *slog.Logger, storage storage.IUserStorage, hasher hasher.IPasswordHasher) *UserService {
 	return &UserService{
 		log:     log,
 		storage: storage,
+		hasher:  hasher,
 	}
 }
 
 // GetUsers implements service.IUserService.
-func (u *UserService) GetUsers(ctx context.Context) ([]models.User, error) {
+func (u *UserService) GetUsers(ctx context.Context, query models.UsersQuery) (models.UsersPage, error) {
 	const op = "service.user.GetUsers"
 	log := u.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
-		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.UsersPage{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	users, err := u.storage.GetUsers(ctx)
+	pageSize := query.PageSize
+	switch {
+	case pageSize <= 0:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+
+	// Logins are stored normalized, so is the prefix they are matched with.
+	filter := models.UsersFilter{
+		LoginPrefix:   models.NormalizeLogin(query.LoginPrefix),
+		CreatedAfter:  query.CreatedAfter,
+		CreatedBefore: query.CreatedBefore,
+		Sort:          query.Sort,
+		// One extra user tells whether there is a next page.
+		Limit: pageSize + 1,
+	}
+
+	if query.PageToken != "" {
+		cursor, err := decodePageToken(query.PageToken, query.Sort)
+		if err != nil {
+			log.WarnContext(ctx, "invalid page token", sl.Err(err))
+			return models.UsersPage{}, fmt.Errorf("%s: %w", op, serviceerror.ErrInvalidPageToken)
+		}
+		filter.After = &cursor
+	}
+
+	users, err := u.storage.GetUsers(ctx, filter)
 	if err != nil {
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("users not found", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
+			log.WarnContext(ctx, "users not found", sl.Err(err))
+			return models.UsersPage{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot fetch users", sl.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return models.UsersPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	page := models.UsersPage{
+		Users: users,
+	}
+	if len(users) > pageSize {
+		page.Users = users[:pageSize]
+		last := page.Users[pageSize-1]
+		page.NextPageToken = encodePageToken(query.Sort, models.UsersCursor{
+			CreatedAt: last.CreatedAt,
+			Login:     last.Login,
+			Id:        last.Id,
+		})
+	}
+
+	return page, nil
+}
+
+// StreamUsers implements service.IUserService. Users are passed to fn in
+// creation order.
+func (u *UserService) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	const op = "service.user.StreamUsers"
+	log := u.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	filter.LoginPrefix = models.NormalizeLogin(filter.LoginPrefix)
+	filter.Sort = models.SortCreatedAtAsc
+	filter.After = nil
+	filter.Limit = 0
+
+	if err := u.storage.StreamUsers(ctx, filter, fn); err != nil {
+		log.ErrorContext(ctx, "cannot stream users", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return users, nil
+	return nil
 }
 
 // GetUserById implements service.IUserService.
 func (u *UserService) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
 	const op = "service.user.GetUserById"
 	log := u.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
@@ -71,11 +143,11 @@ func (u *UserService) GetUserById(ctx context.Context, id uuid.UUID) (models.Use
 	user, err := u.storage.GetUserById(ctx, id)
 	if err != nil {
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot fetch user by id", sl.Err(err))
+		log.ErrorContext(ctx, "cannot fetch user by id", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -86,31 +158,48 @@ func (u *UserService) GetUserById(ctx context.Context, id uuid.UUID) (models.Use
 func (u *UserService) InsertUser(ctx context.Context, user models.User) (models.User, error) {
 	const op = "service.user.InsertUser"
 	log := u.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	user, err := u.storage.InsertUser(ctx, user)
+	hash, err := u.hasher.Hash(user.Password)
 	if err != nil {
+		log.ErrorContext(ctx, "cannot hash password", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.Password = hash
+	user.Login = models.NormalizeLogin(user.Login)
+	user.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	user, err = u.storage.InsertUser(ctx, user)
+	if err != nil {
+		if errors.Is(err, storageerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrLoginTaken)
+		}
+
 		if errors.Is(err, storageerror.ErrAlreadyExists) {
-			log.Warn("user already exists", sl.Err(err))
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrAlreadyExists)
 		}
 
-		log.Error("cannot insert user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return user, nil
 }
 
-func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user models.User) (models.User, error) {
+// UpdateUser implements service.IUserService. With keepLogin, the login is
+// compared with the stored one by the update itself, see
+// validation.Validator.LoginChange.
+func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
 	const op = "service.user.UpdateUser"
 	log := u.log.With(
 		"op", op,
@@ -118,19 +207,37 @@ func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user models.
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	user, err := u.storage.UpdateUser(ctx, id, user)
+	hash, err := u.hasher.Hash(user.Password)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot hash password", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.Password = hash
+	user.Login = models.NormalizeLogin(user.Login)
+
+	user, err = u.storage.UpdateUser(ctx, id, user, keepLogin)
 	if err != nil {
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot upfate user", sl.Err(err))
+		if errors.Is(err, storageerror.ErrLoginChanged) {
+			log.WarnContext(ctx, "login doesn't match the stored one", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrLoginChanged)
+		}
+
+		if errors.Is(err, storageerror.ErrLoginTaken) {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrLoginTaken)
+		}
+
+		log.ErrorContext(ctx, "cannot upfate user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -141,12 +248,12 @@ func (u *UserService) UpdateUser(ctx context.Context, id uuid.UUID, user models.
 func (u *UserService) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
 	const op = "service.user.DeleteUser"
 	log := u.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
@@ -154,11 +261,11 @@ func (u *UserService) DeleteUser(ctx context.Context, id uuid.UUID) (models.User
 	user, err := u.storage.DeleteUser(ctx, id)
 	if err != nil {
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(ctx, "user not found", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, serviceerror.ErrNotFound)
 		}
 
-		log.Error("cannot delete user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
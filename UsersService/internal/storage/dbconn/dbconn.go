@@ -0,0 +1,55 @@
+// Package dbconn waits for the database on start up, so the service doesn't
+// crash when it starts before the database accepts connections.
+package dbconn
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+	"users-service/pkg/config"
+	"users-service/pkg/logger/sl"
+)
+
+// pingTimeout bounds a single ping.
+const pingTimeout = 5 * time.Second
+
+// Wait pings the database with exponential backoff until it answers or
+// cfg.ConnectTimeout passes.
+func Wait(ctx context.Context, log *slog.Logger, ping func(context.Context) error, cfg config.StorageConfig) error {
+	const op = "dbconn.Wait"
+	log = log.With(
+		"op", op,
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	backoff := cfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancelPing := context.WithTimeout(ctx, pingTimeout)
+		err := ping(pingCtx)
+		cancelPing()
+		if err == nil {
+			log.Info("database is reachable", slog.Int("attempt", attempt))
+			return nil
+		}
+
+		// Up to a quarter of jitter, so replicas don't retry in lockstep.
+		delay := backoff + rand.N(backoff/4+1)
+		log.Warn("database is unreachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			sl.Err(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: gave up after %d attempts: %w", op, attempt, err)
+		case <-time.After(delay):
+		}
+
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+}
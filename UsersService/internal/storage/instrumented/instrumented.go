@@ -0,0 +1,37 @@
+// Package instrumented wraps storages to trace their calls and count their
+// errors in metrics.
+package instrumented
+
+import (
+	"context"
+	"errors"
+	"users-service/internal/metrics"
+	storageerror "users-service/internal/storage"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("users-service/internal/storage/instrumented")
+
+func start(ctx context.Context, storage, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "storage."+storage+"."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBOperationName(method)),
+	)
+}
+
+// finish ends the span and counts the error. Not found isn't a failure of
+// the storage, so it doesn't mark the span as failed.
+func finish(span trace.Span, storage, method string, err error) {
+	metrics.StorageError(storage, method, err)
+
+	if err != nil && !errors.Is(err, storageerror.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
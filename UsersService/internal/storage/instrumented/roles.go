@@ -0,0 +1,67 @@
+package instrumented
+
+import (
+	"context"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type RoleStorage struct {
+	next storage.IRoleStorage
+}
+
+func NewRoleStorage(next storage.IRoleStorage) *RoleStorage {
+	return &RoleStorage{
+		next: next,
+	}
+}
+
+// GetRoleByName implements storage.IRoleStorage.
+func (s *RoleStorage) GetRoleByName(ctx context.Context, name string) (models.Role, error) {
+	ctx, span := start(ctx, "role", "GetRoleByName")
+	role, err := s.next.GetRoleByName(ctx, name)
+	finish(span, "role", "GetRoleByName", err)
+	return role, err
+}
+
+// InsertRole implements storage.IRoleStorage.
+func (s *RoleStorage) InsertRole(ctx context.Context, role models.Role) (models.Role, error) {
+	ctx, span := start(ctx, "role", "InsertRole")
+	role, err := s.next.InsertRole(ctx, role)
+	finish(span, "role", "InsertRole", err)
+	return role, err
+}
+
+// GrantRole implements storage.IRoleStorage.
+func (s *RoleStorage) GrantRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	ctx, span := start(ctx, "role", "GrantRole")
+	err := s.next.GrantRole(ctx, userId, roleId)
+	finish(span, "role", "GrantRole", err)
+	return err
+}
+
+// RevokeRole implements storage.IRoleStorage.
+func (s *RoleStorage) RevokeRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	ctx, span := start(ctx, "role", "RevokeRole")
+	err := s.next.RevokeRole(ctx, userId, roleId)
+	finish(span, "role", "RevokeRole", err)
+	return err
+}
+
+// GetUserRoles implements storage.IRoleStorage.
+func (s *RoleStorage) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	ctx, span := start(ctx, "role", "GetUserRoles")
+	roles, err := s.next.GetUserRoles(ctx, userId)
+	finish(span, "role", "GetUserRoles", err)
+	return roles, err
+}
+
+// HasPermission implements storage.IRoleStorage.
+func (s *RoleStorage) HasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	ctx, span := start(ctx, "role", "HasPermission")
+	allowed, err := s.next.HasPermission(ctx, userId, permission)
+	finish(span, "role", "HasPermission", err)
+	return allowed, err
+}
@@ -0,0 +1,91 @@
+package instrumented
+
+import (
+	"context"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type SessionStorage struct {
+	next storage.ISessionStorage
+}
+
+func NewSessionStorage(next storage.ISessionStorage) *SessionStorage {
+	return &SessionStorage{
+		next: next,
+	}
+}
+
+// GetSession implements storage.ISessionStorage.
+func (s *SessionStorage) GetSession(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	ctx, span := start(ctx, "session", "GetSession")
+	session, err := s.next.GetSession(ctx, id)
+	finish(span, "session", "GetSession", err)
+	return session, err
+}
+
+// GetActiveSessions implements storage.ISessionStorage.
+func (s *SessionStorage) GetActiveSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	ctx, span := start(ctx, "session", "GetActiveSessions")
+	sessions, err := s.next.GetActiveSessions(ctx, userId)
+	finish(span, "session", "GetActiveSessions", err)
+	return sessions, err
+}
+
+// InsertSession implements storage.ISessionStorage.
+func (s *SessionStorage) InsertSession(ctx context.Context, session models.Session) (models.Session, error) {
+	ctx, span := start(ctx, "session", "InsertSession")
+	session, err := s.next.InsertSession(ctx, session)
+	finish(span, "session", "InsertSession", err)
+	return session, err
+}
+
+// UpdateSession implements storage.ISessionStorage.
+func (s *SessionStorage) UpdateSession(ctx context.Context, session models.Session) (models.Session, error) {
+	ctx, span := start(ctx, "session", "UpdateSession")
+	session, err := s.next.UpdateSession(ctx, session)
+	finish(span, "session", "UpdateSession", err)
+	return session, err
+}
+
+// RevokeSession implements storage.ISessionStorage.
+func (s *SessionStorage) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	ctx, span := start(ctx, "session", "RevokeSession")
+	err := s.next.RevokeSession(ctx, id)
+	finish(span, "session", "RevokeSession", err)
+	return err
+}
+
+// RevokeUserSessions implements storage.ISessionStorage.
+func (s *SessionStorage) RevokeUserSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	ctx, span := start(ctx, "session", "RevokeUserSessions")
+	revoked, err := s.next.RevokeUserSessions(ctx, userId)
+	finish(span, "session", "RevokeUserSessions", err)
+	return revoked, err
+}
+
+// GetRefreshToken implements storage.ISessionStorage.
+func (s *SessionStorage) GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	ctx, span := start(ctx, "session", "GetRefreshToken")
+	token, err := s.next.GetRefreshToken(ctx, hash)
+	finish(span, "session", "GetRefreshToken", err)
+	return token, err
+}
+
+// InsertRefreshToken implements storage.ISessionStorage.
+func (s *SessionStorage) InsertRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	ctx, span := start(ctx, "session", "InsertRefreshToken")
+	err := s.next.InsertRefreshToken(ctx, token)
+	finish(span, "session", "InsertRefreshToken", err)
+	return err
+}
+
+// UseRefreshToken implements storage.ISessionStorage.
+func (s *SessionStorage) UseRefreshToken(ctx context.Context, hash string) error {
+	ctx, span := start(ctx, "session", "UseRefreshToken")
+	err := s.next.UseRefreshToken(ctx, hash)
+	finish(span, "session", "UseRefreshToken", err)
+	return err
+}
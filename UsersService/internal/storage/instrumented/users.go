@@ -0,0 +1,75 @@
+package instrumented
+
+import (
+	"context"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+type UserStorage struct {
+	next storage.IUserStorage
+}
+
+func NewUserStorage(next storage.IUserStorage) *UserStorage {
+	return &UserStorage{
+		next: next,
+	}
+}
+
+// GetUsers implements storage.IUserStorage.
+func (s *UserStorage) GetUsers(ctx context.Context, filter models.UsersFilter) ([]models.User, error) {
+	ctx, span := start(ctx, "user", "GetUsers")
+	users, err := s.next.GetUsers(ctx, filter)
+	finish(span, "user", "GetUsers", err)
+	return users, err
+}
+
+// StreamUsers implements storage.IUserStorage.
+func (s *UserStorage) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	ctx, span := start(ctx, "user", "StreamUsers")
+	err := s.next.StreamUsers(ctx, filter, fn)
+	finish(span, "user", "StreamUsers", err)
+	return err
+}
+
+// GetUserById implements storage.IUserStorage.
+func (s *UserStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	ctx, span := start(ctx, "user", "GetUserById")
+	user, err := s.next.GetUserById(ctx, id)
+	finish(span, "user", "GetUserById", err)
+	return user, err
+}
+
+// GetUserByLogin implements storage.IUserStorage.
+func (s *UserStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	ctx, span := start(ctx, "user", "GetUserByLogin")
+	user, err := s.next.GetUserByLogin(ctx, login)
+	finish(span, "user", "GetUserByLogin", err)
+	return user, err
+}
+
+// InsertUser implements storage.IUserStorage.
+func (s *UserStorage) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	ctx, span := start(ctx, "user", "InsertUser")
+	user, err := s.next.InsertUser(ctx, user)
+	finish(span, "user", "InsertUser", err)
+	return user, err
+}
+
+// UpdateUser implements storage.IUserStorage.
+func (s *UserStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
+	ctx, span := start(ctx, "user", "UpdateUser")
+	user, err := s.next.UpdateUser(ctx, id, user, keepLogin)
+	finish(span, "user", "UpdateUser", err)
+	return user, err
+}
+
+// DeleteUser implements storage.IUserStorage.
+func (s *UserStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
+	ctx, span := start(ctx, "user", "DeleteUser")
+	user, err := s.next.DeleteUser(ctx, id)
+	finish(span, "user", "DeleteUser", err)
+	return user, err
+}
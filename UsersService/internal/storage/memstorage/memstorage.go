@@ -0,0 +1,240 @@
+// Package memstorage keeps users, sessions and roles in memory, so the
+// service runs without a database in tests and local development. The
+// data can be snapshotted to a JSON file to survive restarts.
+package memstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"users-service/internal/domain/models"
+	"users-service/pkg/config"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// The admin role is seeded into an empty storage, the same as the Postgres
+// migrations do.
+const adminRole = "admin"
+
+var adminPermissions = []string{"roles:manage", "sessions:manage", "users:delete", "users:read", "users:write"}
+
+// MemStorage implements storage.IUserStorage, storage.ISessionStorage and
+// storage.IRoleStorage. All data is guarded by one lock, so operations
+// spanning several kinds of records, like cascading deletes, are atomic.
+type MemStorage struct {
+	log *slog.Logger
+	cfg config.MemoryConfig
+
+	mu            sync.RWMutex
+	users         map[uuid.UUID]models.User
+	logins        map[string]uuid.UUID
+	sessions      map[uuid.UUID]models.Session
+	refreshTokens map[string]models.RefreshToken
+	roles         map[uuid.UUID]models.Role
+	userRoles     map[uuid.UUID]map[uuid.UUID]struct{}
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// snapshot is the JSON file layout.
+type snapshot struct {
+	Users         []models.User             `json:"users"`
+	Sessions      []models.Session          `json:"sessions"`
+	RefreshTokens []models.RefreshToken     `json:"refresh_tokens"`
+	Roles         []models.Role             `json:"roles"`
+	UserRoles     map[uuid.UUID][]uuid.UUID `json:"user_roles"`
+}
+
+// New returns an empty storage, or the one saved at cfg.SnapshotPath if
+// the file exists. With a snapshot path the data is saved every
+// cfg.SnapshotInterval, if set, and on Close.
+func New(log *slog.Logger, cfg config.MemoryConfig) (*MemStorage, error) {
+	const op = "storage.memory.New"
+
+	m := &MemStorage{
+		log:           log,
+		cfg:           cfg,
+		users:         make(map[uuid.UUID]models.User),
+		logins:        make(map[string]uuid.UUID),
+		sessions:      make(map[uuid.UUID]models.Session),
+		refreshTokens: make(map[string]models.RefreshToken),
+		roles:         make(map[uuid.UUID]models.Role),
+		userRoles:     make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		done:          make(chan struct{}),
+	}
+
+	loaded, err := m.load()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !loaded {
+		id := uuid.New()
+		m.roles[id] = models.Role{
+			Id:          id,
+			Name:        adminRole,
+			Description: "Full access to users, sessions and roles",
+			Permissions: adminPermissions,
+		}
+	}
+
+	if cfg.SnapshotPath != "" && cfg.SnapshotInterval > 0 {
+		m.wg.Add(1)
+		go m.snapshotLoop()
+	}
+
+	return m, nil
+}
+
+// PingContext implements storage.IPinger, the storage is always reachable.
+func (m *MemStorage) PingContext(context.Context) error {
+	return nil
+}
+
+// Close stops periodic snapshots and saves the final one.
+func (m *MemStorage) Close() {
+	const op = "storage.memory.Close"
+
+	close(m.done)
+	m.wg.Wait()
+
+	if err := m.Save(); err != nil {
+		m.log.With("op", op).Error("cannot save snapshot", sl.Err(err))
+	}
+}
+
+func (m *MemStorage) snapshotLoop() {
+	const op = "storage.memory.snapshotLoop"
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			if err := m.Save(); err != nil {
+				m.log.With("op", op).Error("cannot save snapshot", sl.Err(err))
+			}
+		}
+	}
+}
+
+// Save writes the snapshot file, if configured. The file is replaced
+// atomically, so a crash mid-write leaves the previous snapshot intact.
+func (m *MemStorage) Save() error {
+	const op = "storage.memory.Save"
+
+	if m.cfg.SnapshotPath == "" {
+		return nil
+	}
+
+	m.mu.RLock()
+	data, err := json.Marshal(m.snapshot())
+	m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.cfg.SnapshotPath), filepath.Base(m.cfg.SnapshotPath)+".*")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := os.Rename(tmp.Name(), m.cfg.SnapshotPath); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// load reads the snapshot file and reports whether there was one.
+func (m *MemStorage) load() (bool, error) {
+	if m.cfg.SnapshotPath == "" {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(m.cfg.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return false, fmt.Errorf("decode %s: %w", m.cfg.SnapshotPath, err)
+	}
+
+	for _, user := range s.Users {
+		m.users[user.Id] = user
+		m.logins[models.NormalizeLogin(user.Login)] = user.Id
+	}
+	for _, session := range s.Sessions {
+		m.sessions[session.Id] = session
+	}
+	for _, token := range s.RefreshTokens {
+		m.refreshTokens[token.Hash] = token
+	}
+	for _, role := range s.Roles {
+		m.roles[role.Id] = role
+	}
+	for userId, roleIds := range s.UserRoles {
+		for _, roleId := range roleIds {
+			m.grant(userId, roleId)
+		}
+	}
+
+	return true, nil
+}
+
+// snapshot copies the data out, the caller holds the lock.
+func (m *MemStorage) snapshot() snapshot {
+	s := snapshot{
+		Users:         make([]models.User, 0, len(m.users)),
+		Sessions:      make([]models.Session, 0, len(m.sessions)),
+		RefreshTokens: make([]models.RefreshToken, 0, len(m.refreshTokens)),
+		Roles:         make([]models.Role, 0, len(m.roles)),
+		UserRoles:     make(map[uuid.UUID][]uuid.UUID, len(m.userRoles)),
+	}
+
+	for _, user := range m.users {
+		s.Users = append(s.Users, user)
+	}
+	for _, session := range m.sessions {
+		s.Sessions = append(s.Sessions, session)
+	}
+	for _, token := range m.refreshTokens {
+		s.RefreshTokens = append(s.RefreshTokens, token)
+	}
+	for _, role := range m.roles {
+		s.Roles = append(s.Roles, role)
+	}
+	for userId, roleIds := range m.userRoles {
+		for roleId := range roleIds {
+			s.UserRoles[userId] = append(s.UserRoles[userId], roleId)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,20 @@
+package memstorage_test
+
+import (
+	"testing"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/storage/memstorage"
+	"users-service/internal/storage/storagetest"
+	"users-service/pkg/config"
+)
+
+func TestMemStorage(t *testing.T) {
+	storagetest.RunUserStorage(t, func(t *testing.T) storage.IUserStorage {
+		s, err := memstorage.New(storagetest.Logger(), config.MemoryConfig{})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		t.Cleanup(s.Close)
+		return s
+	})
+}
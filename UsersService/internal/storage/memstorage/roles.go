@@ -0,0 +1,197 @@
+package memstorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// GetRoleByName implements storage.IRoleStorage.
+func (m *MemStorage) GetRoleByName(ctx context.Context, name string) (models.Role, error) {
+	const op = "storage.memory.GetRoleByName"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Role{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	role, ok := m.roleByName(name)
+	if !ok {
+		log.WarnContext(ctx, "role not found")
+		return models.Role{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return role, nil
+}
+
+// InsertRole implements storage.IRoleStorage.
+func (m *MemStorage) InsertRole(ctx context.Context, role models.Role) (models.Role, error) {
+	const op = "storage.memory.InsertRole"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Role{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.roles[role.Id]; ok {
+		log.WarnContext(ctx, "role already exists")
+		return models.Role{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+	}
+	if _, ok := m.roleByName(role.Name); ok {
+		log.WarnContext(ctx, "role already exists")
+		return models.Role{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+	}
+
+	// Sorted and deduplicated like the permissions read from Postgres.
+	role.Permissions = slices.Compact(slices.Sorted(slices.Values(role.Permissions)))
+	m.roles[role.Id] = role
+
+	return role, nil
+}
+
+// GrantRole implements storage.IRoleStorage. Granting a role twice is a no-op.
+func (m *MemStorage) GrantRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	const op = "storage.memory.GrantRole"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, userExists := m.users[userId]
+	_, roleExists := m.roles[roleId]
+	if !userExists || !roleExists {
+		log.WarnContext(ctx, "user or role doesn't exists")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	m.grant(userId, roleId)
+
+	return nil
+}
+
+// RevokeRole implements storage.IRoleStorage.
+func (m *MemStorage) RevokeRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	const op = "storage.memory.RevokeRole"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.userRoles[userId][roleId]; !ok {
+		log.WarnContext(ctx, "role isn't granted")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	delete(m.userRoles[userId], roleId)
+
+	return nil
+}
+
+// GetUserRoles implements storage.IRoleStorage. Names come sorted.
+func (m *MemStorage) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	const op = "storage.memory.GetUserRoles"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var roles = make([]string, 0, 2)
+	for roleId := range m.userRoles[userId] {
+		roles = append(roles, m.roles[roleId].Name)
+	}
+	slices.Sort(roles)
+
+	return roles, nil
+}
+
+// HasPermission implements storage.IRoleStorage.
+func (m *MemStorage) HasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	const op = "storage.memory.HasPermission"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for roleId := range m.userRoles[userId] {
+		if slices.Contains(m.roles[roleId].Permissions, permission) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// roleByName looks a role up by its unique name, the caller holds the lock.
+func (m *MemStorage) roleByName(name string) (models.Role, bool) {
+	for _, role := range m.roles {
+		if role.Name == name {
+			return role, true
+		}
+	}
+	return models.Role{}, false
+}
+
+// grant records the grant, the caller holds the lock.
+func (m *MemStorage) grant(userId, roleId uuid.UUID) {
+	if m.userRoles[userId] == nil {
+		m.userRoles[userId] = make(map[uuid.UUID]struct{})
+	}
+	m.userRoles[userId][roleId] = struct{}{}
+}
@@ -0,0 +1,294 @@
+package memstorage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// GetSession implements storage.ISessionStorage.
+func (m *MemStorage) GetSession(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	const op = "storage.memory.GetSession"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		log.WarnContext(ctx, "session not found")
+		return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return session, nil
+}
+
+// GetActiveSessions implements storage.ISessionStorage. Sessions come most
+// recently used first.
+func (m *MemStorage) GetActiveSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	const op = "storage.memory.GetActiveSessions"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	var sessions = make([]models.Session, 0, 5)
+	for _, session := range m.sessions {
+		if session.UserId == userId && session.RevokedAt.IsZero() && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b models.Session) int {
+		return b.LastUsedAt.Compare(a.LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// InsertSession implements storage.ISessionStorage. The user must exist.
+func (m *MemStorage) InsertSession(ctx context.Context, session models.Session) (models.Session, error) {
+	const op = "storage.memory.InsertSession"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[session.UserId]; !ok {
+		log.WarnContext(ctx, "user not found")
+		return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+	if _, ok := m.sessions[session.Id]; ok {
+		log.WarnContext(ctx, "session already exists")
+		return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+	}
+
+	session.RevokedAt = time.Time{}
+	m.sessions[session.Id] = session
+
+	return session, nil
+}
+
+// UpdateSession implements storage.ISessionStorage.
+func (m *MemStorage) UpdateSession(ctx context.Context, session models.Session) (models.Session, error) {
+	const op = "storage.memory.UpdateSession"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sessions[session.Id]
+	if !ok {
+		log.WarnContext(ctx, "session not found")
+		return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	stored.UserAgent = session.UserAgent
+	stored.IP = session.IP
+	stored.LastUsedAt = session.LastUsedAt
+	stored.ExpiresAt = session.ExpiresAt
+	m.sessions[session.Id] = stored
+
+	return session, nil
+}
+
+// RevokeSession implements storage.ISessionStorage.
+func (m *MemStorage) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.memory.RevokeSession"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok || !session.RevokedAt.IsZero() {
+		log.WarnContext(ctx, "session not found or already revoked")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	session.RevokedAt = time.Now()
+	m.sessions[id] = session
+
+	return nil
+}
+
+// RevokeUserSessions implements storage.ISessionStorage.
+func (m *MemStorage) RevokeUserSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	const op = "storage.memory.RevokeUserSessions"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var revoked int64
+	for id, session := range m.sessions {
+		if session.UserId == userId && session.RevokedAt.IsZero() {
+			session.RevokedAt = now
+			m.sessions[id] = session
+			revoked++
+		}
+	}
+
+	return revoked, nil
+}
+
+// GetRefreshToken implements storage.ISessionStorage.
+func (m *MemStorage) GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	const op = "storage.memory.GetRefreshToken"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	token, ok := m.refreshTokens[hash]
+	if !ok {
+		log.WarnContext(ctx, "refresh token not found")
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return token, nil
+}
+
+// InsertRefreshToken implements storage.ISessionStorage. The session must exist.
+func (m *MemStorage) InsertRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.memory.InsertRefreshToken"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[token.SessionId]; !ok {
+		log.WarnContext(ctx, "session not found")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+	if _, ok := m.refreshTokens[token.Hash]; ok {
+		log.WarnContext(ctx, "refresh token already exists")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+	}
+
+	token.UsedAt = time.Time{}
+	m.refreshTokens[token.Hash] = token
+
+	return nil
+}
+
+// UseRefreshToken implements storage.ISessionStorage. Out of two concurrent
+// uses of a token exactly one succeeds.
+func (m *MemStorage) UseRefreshToken(ctx context.Context, hash string) error {
+	const op = "storage.memory.UseRefreshToken"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.refreshTokens[hash]
+	if !ok || !token.UsedAt.IsZero() {
+		log.WarnContext(ctx, "refresh token not found or already used")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	token.UsedAt = time.Now()
+	m.refreshTokens[hash] = token
+
+	return nil
+}
+
+// deleteSession deletes the session with its refresh tokens, the caller
+// holds the lock.
+func (m *MemStorage) deleteSession(id uuid.UUID) {
+	delete(m.sessions, id)
+	for hash, token := range m.refreshTokens {
+		if token.SessionId == id {
+			delete(m.refreshTokens, hash)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package memstorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+// GetUsers implements storage.IUserStorage with the same keyset pagination
+// as the Postgres storage. Logins are compared byte-wise.
+func (m *MemStorage) GetUsers(ctx context.Context, filter models.UsersFilter) ([]models.User, error) {
+	const op = "storage.memory.GetUsers"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.selectUsers(filter), nil
+}
+
+// StreamUsers implements storage.IUserStorage. The matching users are copied
+// out first, so fn runs without holding the lock.
+func (m *MemStorage) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	const op = "storage.memory.StreamUsers"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	users := m.selectUsers(filter)
+	m.mu.RUnlock()
+
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(user); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// selectUsers filters, sorts and limits the users, the caller holds the lock.
+func (m *MemStorage) selectUsers(filter models.UsersFilter) []models.User {
+	users := make([]models.User, 0, len(m.users))
+	for _, user := range m.users {
+		if !strings.HasPrefix(user.Login, filter.LoginPrefix) {
+			continue
+		}
+		if !filter.CreatedAfter.IsZero() && user.CreatedAt.Before(filter.CreatedAfter) {
+			continue
+		}
+		if !filter.CreatedBefore.IsZero() && !user.CreatedAt.Before(filter.CreatedBefore) {
+			continue
+		}
+		if filter.After != nil && compareUsers(filter.Sort, user, cursorUser(*filter.After)) <= 0 {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	slices.SortFunc(users, func(a, b models.User) int {
+		return compareUsers(filter.Sort, a, b)
+	})
+
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+
+	return users
+}
+
+// compareUsers orders users by the sort key, then by id.
+func compareUsers(sort models.UserSort, a, b models.User) int {
+	var c int
+	switch sort {
+	case models.SortLoginAsc, models.SortLoginDesc:
+		c = strings.Compare(a.Login, b.Login)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = bytes.Compare(a.Id[:], b.Id[:])
+	}
+
+	if sort == models.SortCreatedAtDesc || sort == models.SortLoginDesc {
+		return -c
+	}
+	return c
+}
+
+func cursorUser(cursor models.UsersCursor) models.User {
+	return models.User{
+		Id:        cursor.Id,
+		Login:     cursor.Login,
+		CreatedAt: cursor.CreatedAt,
+	}
+}
+
+// GetUserById implements storage.IUserStorage.
+func (m *MemStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.memory.GetUserById"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		log.WarnContext(ctx, "user not found")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return user, nil
+}
+
+// GetUserByLogin implements storage.IUserStorage.
+func (m *MemStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.memory.GetUserByLogin"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Logins are unique once normalized, but looked up as they are, like
+	// in the SQL storages.
+	id, ok := m.logins[models.NormalizeLogin(login)]
+	if !ok || m.users[id].Login != login {
+		log.WarnContext(ctx, "user not found")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return m.users[id], nil
+}
+
+// InsertUser implements storage.IUserStorage. Both the id and the login
+// must be free.
+func (m *MemStorage) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	const op = "storage.memory.InsertUser"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[user.Id]; ok {
+		log.WarnContext(ctx, "user already exists")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+	}
+	if _, ok := m.logins[models.NormalizeLogin(user.Login)]; ok {
+		log.WarnContext(ctx, "login is taken")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginTaken)
+	}
+
+	m.users[user.Id] = user
+	m.logins[models.NormalizeLogin(user.Login)] = user.Id
+
+	return user, nil
+}
+
+// UpdateUser implements storage.IUserStorage.
+func (m *MemStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
+	const op = "storage.memory.UpdateUser"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.users[id]
+	if !ok {
+		log.WarnContext(ctx, "user not found")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+	if keepLogin && models.NormalizeLogin(user.Login) != models.NormalizeLogin(stored.Login) {
+		log.WarnContext(ctx, "login doesn't match the stored one")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginChanged)
+	}
+	if owner, ok := m.logins[models.NormalizeLogin(user.Login)]; ok && owner != id {
+		log.WarnContext(ctx, "login is taken")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginTaken)
+	}
+
+	delete(m.logins, models.NormalizeLogin(stored.Login))
+	stored.Login = user.Login
+	stored.Password = user.Password
+	m.users[id] = stored
+	m.logins[models.NormalizeLogin(stored.Login)] = id
+
+	return stored, nil
+}
+
+// DeleteUser implements storage.IUserStorage. Sessions and role grants of
+// the user are deleted along with it.
+func (m *MemStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.memory.DeleteUser"
+	log := m.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		log.WarnContext(ctx, "user doesn't exists")
+		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	delete(m.users, id)
+	delete(m.logins, models.NormalizeLogin(user.Login))
+	delete(m.userRoles, id)
+	for sessionId, session := range m.sessions {
+		if session.UserId == id {
+			m.deleteSession(sessionId)
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,99 @@
+// Package migrator applies the embedded migrations with goose.
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"users-service/migrations"
+
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	CommandUp      = "up"
+	CommandDown    = "down"
+	CommandStatus  = "status"
+	CommandRedo    = "redo"
+	CommandVersion = "version"
+)
+
+// Dialects of the migration sets.
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite3"
+)
+
+// Commands lists the goose commands Run accepts.
+var Commands = []string{CommandUp, CommandDown, CommandStatus, CommandRedo, CommandVersion}
+
+type Migrator struct {
+	log *slog.Logger
+	db  *sql.DB
+	dir string
+}
+
+// New returns a migrator applying the migration set of the dialect.
+func New(log *slog.Logger, db *sql.DB, dialect string) (*Migrator, error) {
+	const op = "migrator.New"
+
+	// Go migrations are registered globally, so they're reset in case the
+	// previous migrator was of another dialect.
+	goose.ResetGlobalMigrations()
+
+	dir := "."
+	if dialect == DialectSQLite {
+		dir = migrations.SQLiteDir
+	} else if err := goose.SetGlobalMigrations(migrations.Postgres()...); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	goose.SetBaseFS(migrations.FS)
+	goose.SetLogger(gooseLogger{log: log})
+	if err := goose.SetDialect(dialect); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Migrator{
+		log: log,
+		db:  db,
+		dir: dir,
+	}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Run(ctx, CommandUp)
+}
+
+// Run runs one of Commands against the database.
+func (m *Migrator) Run(ctx context.Context, command string) error {
+	const op = "migrator.Run"
+
+	if !slices.Contains(Commands, command) {
+		return fmt.Errorf("%s: unknown command %q, want one of %s", op, command, strings.Join(Commands, ", "))
+	}
+
+	if err := goose.RunContext(ctx, command, m.db, m.dir); err != nil {
+		return fmt.Errorf("%s: %s: %w", op, command, err)
+	}
+
+	return nil
+}
+
+// gooseLogger routes goose output, such as applied migrations and the
+// status table, to slog.
+type gooseLogger struct {
+	log *slog.Logger
+}
+
+func (l gooseLogger) Printf(format string, v ...any) {
+	l.log.Info(strings.TrimSpace(fmt.Sprintf(format, v...)), slog.String("component", "goose"))
+}
+
+func (l gooseLogger) Fatalf(format string, v ...any) {
+	panic(fmt.Sprintf(format, v...))
+}
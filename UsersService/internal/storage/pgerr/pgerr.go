@@ -0,0 +1,79 @@
+// Package pgerr reads Postgres error codes whichever driver reported them:
+// lib/pq or pgx, natively or through database/sql. SQLite constraint errors
+// are translated to the equivalent Postgres codes, so storages running on
+// both databases check errors once.
+package pgerr
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/glebarez/go-sqlite"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// Extended result codes of SQLite constraint errors.
+const (
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// sqliteIndex finds the index in the message of a violated unique index on
+// an expression, SQLite reports no constraint name otherwise.
+var sqliteIndex = regexp.MustCompile(`index '([^']+)'`)
+
+// Code returns the SQLSTATE code of err, or "" if err doesn't come from
+// the database or has no Postgres equivalent.
+func Code(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqliteConstraintPrimaryKey, sqliteConstraintUnique:
+			return UniqueViolation
+		case sqliteConstraintForeignKey:
+			return ForeignKeyViolation
+		}
+	}
+
+	return ""
+}
+
+// Constraint returns the name of the constraint or unique index err
+// violates, or "" if it is unknown.
+func Constraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		if match := sqliteIndex.FindStringSubmatch(sqliteErr.Error()); match != nil {
+			return match[1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,343 @@
+// Package pgxstorage implements storage.IUserStorage on a native pgx pool.
+// Fixed queries are prepared under a name on every new connection, so they
+// are parsed and planned once per connection instead of once per call.
+package pgxstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/internal/storage/dbconn"
+	"users-service/internal/storage/userstorage"
+	"users-service/pkg/config"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const userColumns = "id, login, password, created_at"
+
+// Names of the prepared statements.
+const (
+	stmtUserById    = "user_by_id"
+	stmtUserByLogin = "user_by_login"
+	stmtInsertUser  = "insert_user"
+	stmtUpdateUser  = "update_user"
+	stmtDeleteUser  = "delete_user"
+)
+
+var statements = map[string]string{
+	stmtUserById: `
+		SELECT ` + userColumns + ` FROM ` + userstorage.UsersTableName + `
+		WHERE id=$1`,
+	stmtUserByLogin: `
+		SELECT ` + userColumns + ` FROM ` + userstorage.UsersTableName + `
+		WHERE login=$1`,
+	stmtInsertUser: `
+		INSERT INTO ` + userstorage.UsersTableName + `(id, login, password, created_at)
+		VALUES($1, $2, $3, $4)`,
+	stmtUpdateUser: `
+		UPDATE ` + userstorage.UsersTableName + `
+		SET login=$1, password=$2
+		WHERE id=$3 AND (NOT $4::boolean OR login=$1)
+		RETURNING ` + userColumns,
+	stmtDeleteUser: `
+		DELETE FROM ` + userstorage.UsersTableName + `
+		WHERE id=$1
+		RETURNING ` + userColumns,
+}
+
+type PgxStorage struct {
+	log  *slog.Logger
+	Pool *pgxpool.Pool
+}
+
+// New opens a pool sized by cfg and waits for the database to answer
+// pings, see dbconn.Wait.
+func New(log *slog.Logger, connStr string, cfg config.StorageConfig) *PgxStorage {
+	const op = "pgx.New"
+
+	poolCfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		log.With(slog.String("op", op)).Error("Invalid connection string", sl.Err(err))
+		panic(err)
+	}
+
+	poolCfg.MaxConns = int32(cfg.MaxOpenConns)
+	poolCfg.MaxConnLifetime = cfg.ConnMaxLifetime
+	poolCfg.MaxConnIdleTime = cfg.ConnMaxIdleTime
+	poolCfg.AfterConnect = prepare
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
+	if err != nil {
+		log.With(slog.String("op", op)).Error("Error connecting to DB", sl.Err(err))
+		panic(err)
+	}
+
+	if err := dbconn.Wait(context.Background(), log, pool.Ping, cfg); err != nil {
+		log.With(slog.String("op", op)).Error("Database is unreachable", sl.Err(err))
+		pool.Close()
+		panic(err)
+	}
+
+	return &PgxStorage{
+		log:  log,
+		Pool: pool,
+	}
+}
+
+// prepare prepares the statements on a new connection.
+func prepare(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range statements {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return fmt.Errorf("prepare %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (p *PgxStorage) Close() {
+	p.Pool.Close()
+}
+
+// GetUsers implements storage.IUserStorage. The query depends on the filter,
+// so it isn't prepared by name; pgx still caches its statement per connection.
+func (p *PgxStorage) GetUsers(ctx context.Context, filter models.UsersFilter) ([]models.User, error) {
+	const op = "storage.pgx.GetUsers"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query, args := userstorage.UsersQuery(filter)
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.User, error) {
+		return scanUser(row)
+	})
+	if err != nil {
+		log.ErrorContext(ctx, "cannot scan users", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// StreamUsers implements storage.IUserStorage. Users are passed to fn as
+// they are read, an error returned by fn stops the iteration.
+func (p *PgxStorage) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	const op = "storage.pgx.StreamUsers"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query, args := userstorage.UsersQuery(filter)
+	rows, err := p.Pool.Query(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(user); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUserById implements storage.IUserStorage.
+func (p *PgxStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.pgx.GetUserById"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(p.Pool.QueryRow(ctx, stmtUserById, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetUserByLogin implements storage.IUserStorage.
+func (p *PgxStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.pgx.GetUserByLogin"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(p.Pool.QueryRow(ctx, stmtUserByLogin, login))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// InsertUser implements storage.IUserStorage.
+func (p *PgxStorage) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	const op = "storage.pgx.InsertUser"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := p.Pool.Exec(ctx, stmtInsertUser, user.Id, user.Login, user.Password, user.CreatedAt)
+	if err != nil {
+		if conflict := userstorage.Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
+		}
+
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser implements storage.IUserStorage.
+func (p *PgxStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
+	const op = "storage.pgx.UpdateUser"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	updated, err := scanUser(p.Pool.QueryRow(ctx, stmtUpdateUser, user.Login, user.Password, id, keepLogin))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Either the user is missing or its login isn't the one given.
+			if keepLogin {
+				if _, err := p.GetUserById(ctx, id); err != nil {
+					return models.User{}, fmt.Errorf("%s: %w", op, err)
+				}
+
+				log.WarnContext(ctx, "login doesn't match the stored one")
+				return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginChanged)
+			}
+
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		if conflict := userstorage.Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
+		}
+
+		log.ErrorContext(ctx, "cannot update user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteUser implements storage.IUserStorage.
+func (p *PgxStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.pgx.DeleteUser"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(p.Pool.QueryRow(ctx, stmtDeleteUser, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func scanUser(row pgx.Row) (models.User, error) {
+	var user models.User
+	err := row.Scan(&user.Id, &user.Login, &user.Password, &user.CreatedAt)
+	return user, err
+}
@@ -0,0 +1,23 @@
+package pgxstorage_test
+
+import (
+	"testing"
+	"time"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/storage/pgxstorage"
+	"users-service/internal/storage/storagetest"
+	"users-service/pkg/config"
+)
+
+func TestPgxStorage(t *testing.T) {
+	storagetest.RunUserStorage(t, func(t *testing.T) storage.IUserStorage {
+		s := pgxstorage.New(storagetest.Logger(), storagetest.PostgresDSN(t), config.StorageConfig{
+			MaxOpenConns:   4,
+			ConnectTimeout: 5 * time.Second,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     time.Second,
+		})
+		t.Cleanup(s.Close)
+		return s
+	})
+}
@@ -0,0 +1,304 @@
+package rolestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/internal/storage/pgerr"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+type PsqlStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+const (
+	RolesTableName           = "Roles"
+	PermissionsTableName     = "Permissions"
+	RolePermissionsTableName = "Role_Permissions"
+	UserRolesTableName       = "User_Roles"
+)
+
+func New(log *slog.Logger, db *sql.DB) *PsqlStorage {
+	return &PsqlStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// GetRoleByName implements storage.IRoleStorage.
+func (p *PsqlStorage) GetRoleByName(ctx context.Context, name string) (models.Role, error) {
+	const op = "storage.role.GetRoleByName"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Role{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var role models.Role
+	err := p.DB.QueryRowContext(ctx, `
+		SELECT id, name, description FROM `+RolesTableName+`
+		WHERE name=$1
+	`, name).Scan(&role.Id, &role.Name, &role.Description)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "role not found", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := p.DB.QueryContext(ctx, `
+		SELECT p.name FROM `+PermissionsTableName+` p
+		JOIN `+RolePermissionsTableName+` rp ON rp.permission_id = p.id
+		WHERE rp.role_id=$1
+		ORDER BY p.name
+	`, role.Id)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch role permissions", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		role.Permissions = append(role.Permissions, permission)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+// InsertRole implements storage.IRoleStorage. Permissions missing from the
+// permissions table are created along with the role.
+func (p *PsqlStorage) InsertRole(ctx context.Context, role models.Role) (models.Role, error) {
+	const op = "storage.role.InsertRole"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Role{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot begin transaction", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO `+RolesTableName+`(id, name, description)
+		VALUES($1, $2, $3);
+	`, role.Id, role.Name, role.Description)
+	if err != nil {
+		if pgerr.Code(err) == pgerr.UniqueViolation {
+			log.WarnContext(ctx, "role already exists", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+		}
+
+		log.ErrorContext(ctx, "cannot insert role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, permission := range role.Permissions {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO `+PermissionsTableName+`(id, name)
+			VALUES($1, $2)
+			ON CONFLICT (name) DO NOTHING;
+		`, uuid.New(), permission)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot insert permission", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO `+RolePermissionsTableName+`(role_id, permission_id)
+			SELECT $1, id FROM `+PermissionsTableName+` WHERE name=$2
+			ON CONFLICT DO NOTHING;
+		`, role.Id, permission)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot grant permission to role", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.ErrorContext(ctx, "cannot commit transaction", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return role, nil
+}
+
+// GrantRole implements storage.IRoleStorage. Granting a role twice is a no-op.
+func (p *PsqlStorage) GrantRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	const op = "storage.role.GrantRole"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := p.DB.ExecContext(ctx, `
+		INSERT INTO `+UserRolesTableName+`(user_id, role_id)
+		VALUES($1, $2)
+		ON CONFLICT DO NOTHING;
+	`, userId, roleId)
+	if err != nil {
+		if pgerr.Code(err) == pgerr.ForeignKeyViolation {
+			log.WarnContext(ctx, "user or role doesn't exists", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot grant role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeRole implements storage.IRoleStorage.
+func (p *PsqlStorage) RevokeRole(ctx context.Context, userId, roleId uuid.UUID) error {
+	const op = "storage.role.RevokeRole"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := p.DB.ExecContext(ctx, `
+		DELETE FROM `+UserRolesTableName+`
+		WHERE user_id=$1 AND role_id=$2;
+	`, userId, roleId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot revoke role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.ErrorContext(ctx, "Error get rows affected", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		log.WarnContext(ctx, "Zero rows affected")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// GetUserRoles implements storage.IRoleStorage.
+func (p *PsqlStorage) GetUserRoles(ctx context.Context, userId uuid.UUID) ([]string, error) {
+	const op = "storage.role.GetUserRoles"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := p.DB.QueryContext(ctx, `
+		SELECT r.name FROM `+RolesTableName+` r
+		JOIN `+UserRolesTableName+` ur ON ur.role_id = r.id
+		WHERE ur.user_id=$1
+		ORDER BY r.name
+	`, userId)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch user roles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var roles = make([]string, 0, 2)
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return roles, nil
+}
+
+// HasPermission implements storage.IRoleStorage.
+func (p *PsqlStorage) HasPermission(ctx context.Context, userId uuid.UUID, permission string) (bool, error) {
+	const op = "storage.role.HasPermission"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return false, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var allowed bool
+	err := p.DB.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM `+UserRolesTableName+` ur
+			JOIN `+RolePermissionsTableName+` rp ON rp.role_id = ur.role_id
+			JOIN `+PermissionsTableName+` p ON p.id = rp.permission_id
+			WHERE ur.user_id=$1 AND p.name=$2
+		)
+	`, userId, permission).Scan(&allowed)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot check permission", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return allowed, nil
+}
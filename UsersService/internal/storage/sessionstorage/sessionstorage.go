@@ -0,0 +1,368 @@
+package sessionstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/pkg/logger/sl"
+
+	"github.com/google/uuid"
+)
+
+type PsqlStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+const (
+	SessionsTableName      = "Sessions"
+	RefreshTokensTableName = "RefreshTokens"
+)
+
+const sessionColumns = "id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at"
+
+func New(log *slog.Logger, db *sql.DB) *PsqlStorage {
+	return &PsqlStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+// GetSession implements storage.ISessionStorage.
+func (p *PsqlStorage) GetSession(ctx context.Context, id uuid.UUID) (models.Session, error) {
+	const op = "storage.session.GetSession"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	session, err := scanSession(p.DB.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+` FROM `+SessionsTableName+`
+		WHERE id=$1
+	`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "session not found", sl.Err(err))
+			return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// GetActiveSessions implements storage.ISessionStorage.
+func (p *PsqlStorage) GetActiveSessions(ctx context.Context, userId uuid.UUID) ([]models.Session, error) {
+	const op = "storage.session.GetActiveSessions"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	rows, err := p.DB.QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM `+SessionsTableName+`
+		WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`, userId, time.Now().UTC())
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions = make([]models.Session, 0, 5)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// InsertSession implements storage.ISessionStorage.
+func (p *PsqlStorage) InsertSession(ctx context.Context, session models.Session) (models.Session, error) {
+	const op = "storage.session.InsertSession"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := p.DB.ExecContext(ctx, `
+		INSERT INTO `+SessionsTableName+`(id, user_id, user_agent, ip, created_at, last_used_at, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7);
+	`, session.Id, session.UserId, session.UserAgent, session.IP, session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot insert session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// UpdateSession implements storage.ISessionStorage.
+func (p *PsqlStorage) UpdateSession(ctx context.Context, session models.Session) (models.Session, error) {
+	const op = "storage.session.UpdateSession"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.Session{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := p.DB.ExecContext(ctx, `
+		UPDATE `+SessionsTableName+`
+		SET user_agent=$1, ip=$2, last_used_at=$3, expires_at=$4
+		WHERE id=$5
+	`, session.UserAgent, session.IP, session.LastUsedAt, session.ExpiresAt, session.Id)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update session", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.ErrorContext(ctx, "Error get rows affected", sl.Err(err))
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		log.ErrorContext(ctx, "Zero rows affected")
+		return models.Session{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return session, nil
+}
+
+// RevokeSession implements storage.ISessionStorage.
+func (p *PsqlStorage) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	const op = "storage.session.RevokeSession"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := p.DB.ExecContext(ctx, `
+		UPDATE `+SessionsTableName+`
+		SET revoked_at=$1
+		WHERE id=$2 AND revoked_at IS NULL
+	`, time.Now().UTC(), id)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to revoke session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.ErrorContext(ctx, "Error get rows affected", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		log.WarnContext(ctx, "Zero rows affected")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions implements storage.ISessionStorage.
+func (p *PsqlStorage) RevokeUserSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	const op = "storage.session.RevokeUserSessions"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := p.DB.ExecContext(ctx, `
+		UPDATE `+SessionsTableName+`
+		SET revoked_at=$1
+		WHERE user_id=$2 AND revoked_at IS NULL
+	`, time.Now().UTC(), userId)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to revoke sessions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.ErrorContext(ctx, "Error get rows affected", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rowsAffected, nil
+}
+
+// GetRefreshToken implements storage.ISessionStorage.
+func (p *PsqlStorage) GetRefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	const op = "storage.session.GetRefreshToken"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var (
+		token  models.RefreshToken
+		usedAt sql.NullTime
+	)
+	err := p.DB.QueryRowContext(ctx, `
+		SELECT token_hash, session_id, created_at, used_at FROM `+RefreshTokensTableName+`
+		WHERE token_hash=$1
+	`, hash).Scan(&token.Hash, &token.SessionId, &token.CreatedAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "refresh token not found", sl.Err(err))
+			return models.RefreshToken{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan refresh token", sl.Err(err))
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token.UsedAt = usedAt.Time
+
+	return token, nil
+}
+
+// InsertRefreshToken implements storage.ISessionStorage.
+func (p *PsqlStorage) InsertRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.session.InsertRefreshToken"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := p.DB.ExecContext(ctx, `
+		INSERT INTO `+RefreshTokensTableName+`(token_hash, session_id, created_at)
+		VALUES($1, $2, $3);
+	`, token.Hash, token.SessionId, token.CreatedAt)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot insert refresh token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseRefreshToken implements storage.ISessionStorage. It marks the token as
+// used only if it wasn't used before, so out of two concurrent refreshes
+// with the same token exactly one succeeds.
+func (p *PsqlStorage) UseRefreshToken(ctx context.Context, hash string) error {
+	const op = "storage.session.UseRefreshToken"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	result, err := p.DB.ExecContext(ctx, `
+		UPDATE `+RefreshTokensTableName+`
+		SET used_at=$1
+		WHERE token_hash=$2 AND used_at IS NULL
+	`, time.Now().UTC(), hash)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to use refresh token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.ErrorContext(ctx, "Error get rows affected", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		log.WarnContext(ctx, "Zero rows affected")
+		return fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row scanner) (models.Session, error) {
+	var (
+		session   models.Session
+		revokedAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&session.Id, &session.UserId, &session.UserAgent, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt,
+	)
+	if err != nil {
+		return models.Session{}, err
+	}
+	session.RevokedAt = revokedAt.Time
+
+	return session, nil
+}
@@ -0,0 +1,366 @@
+// Package sqlitestorage implements storage.IUserStorage on SQLite, for
+// single node deployments without Postgres. The driver is pure Go, so the
+// binary still builds without cgo.
+//
+// SQLite compares times as text, so they are written in UTC to keep them
+// ordered.
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+	"users-service/internal/storage/userstorage"
+	"users-service/pkg/config"
+	"users-service/pkg/logger/sl"
+
+	_ "github.com/glebarez/go-sqlite" // registers the "sqlite" driver
+	"github.com/google/uuid"
+)
+
+const userColumns = "id, login, password, created_at"
+
+// pragmas are set on every connection: foreign keys for the cascades of
+// the schema, WAL so reads don't wait for writes, a busy timeout so
+// concurrent writers queue instead of failing, and case sensitive LIKE to
+// filter logins the way Postgres does.
+var pragmas = []string{
+	"foreign_keys(1)",
+	"journal_mode(WAL)",
+	"busy_timeout(5000)",
+	"case_sensitive_like(1)",
+}
+
+type SQLiteStorage struct {
+	log *slog.Logger
+	DB  *sql.DB
+}
+
+// Open opens the database file at path, creating it if needed.
+func Open(path string) (*sql.DB, error) {
+	query := url.Values{}
+	for _, pragma := range pragmas {
+		query.Add("_pragma", pragma)
+	}
+	query.Set("_time_format", "sqlite")
+
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
+
+// New opens the database at cfg.SQLite.Path. The schema is expected to be
+// migrated already, see migrator.DialectSQLite.
+func New(log *slog.Logger, cfg config.StorageConfig) *SQLiteStorage {
+	const op = "sqlite.New"
+
+	db, err := Open(cfg.SQLite.Path)
+	if err != nil {
+		log.With(slog.String("op", op)).Error("Error opening DB", sl.Err(err))
+		panic(err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := db.PingContext(context.Background()); err != nil {
+		log.With(slog.String("op", op)).Error("Error opening DB", sl.Err(err))
+		db.Close()
+		panic(err)
+	}
+
+	return &SQLiteStorage{
+		log: log,
+		DB:  db,
+	}
+}
+
+func (s *SQLiteStorage) Close() {
+	s.DB.Close()
+}
+
+// GetUsers implements storage.IUserStorage with the same keyset pagination
+// as the Postgres storage.
+func (s *SQLiteStorage) GetUsers(ctx context.Context, filter models.UsersFilter) ([]models.User, error) {
+	const op = "storage.sqlite.GetUsers"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query, args := usersQuery(filter)
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users = make([]models.User, 0, filter.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// StreamUsers implements storage.IUserStorage. Users are passed to fn as
+// they are read, an error returned by fn stops the iteration.
+func (s *SQLiteStorage) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	const op = "storage.sqlite.StreamUsers"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query, args := usersQuery(filter)
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(user); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// usersQuery reuses the Postgres query, which SQLite runs as is, with the
+// times converted to UTC.
+func usersQuery(filter models.UsersFilter) (string, []any) {
+	query, args := userstorage.UsersQuery(filter)
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			args[i] = t.UTC()
+		}
+	}
+	return query, args
+}
+
+// GetUserById implements storage.IUserStorage.
+func (s *SQLiteStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.sqlite.GetUserById"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(s.DB.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM `+userstorage.UsersTableName+`
+		WHERE id=$1
+	`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetUserByLogin implements storage.IUserStorage.
+func (s *SQLiteStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.sqlite.GetUserByLogin"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(s.DB.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM `+userstorage.UsersTableName+`
+		WHERE login=$1
+	`, login))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// InsertUser implements storage.IUserStorage.
+func (s *SQLiteStorage) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	const op = "storage.sqlite.InsertUser"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO `+userstorage.UsersTableName+`(id, login, password, created_at)
+		VALUES($1, $2, $3, $4);
+	`, user.Id, user.Login, user.Password, user.CreatedAt.UTC())
+	if err != nil {
+		if conflict := userstorage.Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
+		}
+
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// UpdateUser implements storage.IUserStorage.
+func (s *SQLiteStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
+	const op = "storage.sqlite.UpdateUser"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	updated, err := scanUser(s.DB.QueryRowContext(ctx, `
+		UPDATE `+userstorage.UsersTableName+`
+		SET login=$1, password=$2
+		WHERE id=$3 AND (NOT $4 OR login=$1)
+		RETURNING `+userColumns+`
+	`, user.Login, user.Password, id, keepLogin))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Either the user is missing or its login isn't the one given.
+			if keepLogin {
+				if _, err := s.GetUserById(ctx, id); err != nil {
+					return models.User{}, fmt.Errorf("%s: %w", op, err)
+				}
+
+				log.WarnContext(ctx, "login doesn't match the stored one")
+				return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginChanged)
+			}
+
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		if conflict := userstorage.Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
+		}
+
+		log.ErrorContext(ctx, "cannot update user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+// DeleteUser implements storage.IUserStorage.
+func (s *SQLiteStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
+	const op = "storage.sqlite.DeleteUser"
+	log := s.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	user, err := scanUser(s.DB.QueryRowContext(ctx, `
+		DELETE FROM `+userstorage.UsersTableName+`
+		WHERE id=$1
+		RETURNING `+userColumns+`
+	`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row scanner) (models.User, error) {
+	var user models.User
+	err := row.Scan(&user.Id, &user.Login, &user.Password, &user.CreatedAt)
+	return user, err
+}
@@ -0,0 +1,38 @@
+package sqlitestorage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/storage/migrator"
+	"users-service/internal/storage/sqlitestorage"
+	"users-service/internal/storage/storagetest"
+	"users-service/pkg/config"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	storagetest.RunUserStorage(t, func(t *testing.T) storage.IUserStorage {
+		cfg := config.StorageConfig{
+			SQLite: config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "users.db")},
+		}
+
+		db, err := sqlitestorage.Open(cfg.SQLite.Path)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer db.Close()
+
+		m, err := migrator.New(storagetest.Logger(), db, migrator.DialectSQLite)
+		if err != nil {
+			t.Fatalf("migrator: %v", err)
+		}
+		if err := m.Up(context.Background()); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+
+		s := sqlitestorage.New(storagetest.Logger(), cfg)
+		t.Cleanup(s.Close)
+		return s
+	})
+}
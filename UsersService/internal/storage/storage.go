@@ -1,8 +1,17 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrLoginTaken is the ErrAlreadyExists of a user whose login belongs
+	// to another one.
+	ErrLoginTaken = fmt.Errorf("login is taken: %w", ErrAlreadyExists)
+	// ErrLoginChanged is returned by updates that must keep the login of
+	// the user when the login given isn't the stored one.
+	ErrLoginChanged = errors.New("login doesn't match the stored one")
 )
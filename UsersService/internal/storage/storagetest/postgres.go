@@ -0,0 +1,94 @@
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"users-service/internal/storage/migrator"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+)
+
+// PostgresDSNEnv points the Postgres backends at a local instance, their
+// tests are skipped when it isn't set.
+const PostgresDSNEnv = "USERS_TEST_POSTGRES_DSN"
+
+// PostgresDSN returns a DSN of the local instance whose search_path is a
+// fresh, migrated schema dropped when the test ends. Every test gets its own
+// schema, so packages sharing the instance can run in parallel. The test is
+// skipped when PostgresDSNEnv isn't set.
+func PostgresDSN(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", PostgresDSNEnv)
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer admin.Close()
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("pgx", dsn)
+		if err != nil {
+			t.Errorf("open: %v", err)
+			return
+		}
+		defer admin.Close()
+
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	dsn, err = withSearchPath(dsn, schema)
+	if err != nil {
+		t.Fatalf("dsn: %v", err)
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	m, err := migrator.New(Logger(), db, migrator.DialectPostgres)
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return dsn
+}
+
+// withSearchPath sets the search_path run-time parameter of a URL or a
+// keyword/value DSN.
+func withSearchPath(dsn, schema string) (string, error) {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema, nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
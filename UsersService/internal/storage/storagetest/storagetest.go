@@ -0,0 +1,542 @@
+// Package storagetest is a conformance suite for storage.IUserStorage, run
+// by the tests of every implementation so they all behave the same.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/domain/models"
+	storageerror "users-service/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty storage. It is called once per test, cleanup is
+// registered on t.
+type Factory func(t *testing.T) storage.IUserStorage
+
+// Logger discards the logs of the storage under test.
+func Logger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// NewUser returns a user with a random id, created at a whole second so it
+// survives the time precision of every backend.
+func NewUser(login string) models.User {
+	return models.User{
+		Id:        uuid.New(),
+		Login:     login,
+		Password:  "hash-of-" + login,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// RunUserStorage runs the suite against the storages returned by newStorage.
+func RunUserStorage(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.IUserStorage)
+	}{
+		{"InsertAndGet", testInsertAndGet},
+		{"GetNotFound", testGetNotFound},
+		{"InsertDuplicateId", testInsertDuplicateId},
+		{"InsertLoginTaken", testInsertLoginTaken},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"UpdateLoginTaken", testUpdateLoginTaken},
+		{"UpdateKeepingLogin", testUpdateKeepingLogin},
+		{"Delete", testDelete},
+		{"DeleteNotFound", testDeleteNotFound},
+		{"GetUsers", testGetUsers},
+		{"CancelledContext", testCancelledContext},
+		{"ConcurrentInserts", testConcurrentInserts},
+		{"ConcurrentDuplicateInserts", testConcurrentDuplicateInserts},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func testInsertAndGet(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := NewUser("alice")
+
+	inserted, err := s.InsertUser(ctx, user)
+	if err != nil {
+		t.Fatalf("InsertUser: %v", err)
+	}
+	assertUser(t, "InsertUser", inserted, user)
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById", got, user)
+
+	got, err = s.GetUserByLogin(ctx, user.Login)
+	if err != nil {
+		t.Fatalf("GetUserByLogin: %v", err)
+	}
+	assertUser(t, "GetUserByLogin", got, user)
+}
+
+func testGetNotFound(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+
+	if _, err := s.GetUserById(ctx, uuid.New()); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Errorf("GetUserById of a missing user: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+	if _, err := s.GetUserByLogin(ctx, "nobody"); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Errorf("GetUserByLogin of a missing user: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+}
+
+func testInsertDuplicateId(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	duplicate := NewUser("bob")
+	duplicate.Id = user.Id
+	_, err := s.InsertUser(ctx, duplicate)
+	if !errors.Is(err, storageerror.ErrAlreadyExists) || errors.Is(err, storageerror.ErrLoginTaken) {
+		t.Fatalf("InsertUser with a taken id: got %v, want %v", err, storageerror.ErrAlreadyExists)
+	}
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the duplicate", got, user)
+}
+
+// testInsertLoginTaken inserts a login differing from a stored one by case
+// and spaces only, which normalize to the same login.
+func testInsertLoginTaken(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	mustInsert(t, s, NewUser("alice"))
+
+	if _, err := s.InsertUser(ctx, NewUser(" ALICE ")); !errors.Is(err, storageerror.ErrLoginTaken) {
+		t.Fatalf("InsertUser with a taken login: got %v, want %v", err, storageerror.ErrLoginTaken)
+	}
+
+	got, err := s.GetUsers(ctx, models.UsersFilter{})
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	assertLogins(t, got, "alice")
+}
+
+func testUpdate(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	want := user
+	want.Login = "alice2"
+	want.Password = "new-hash"
+
+	updated, err := s.UpdateUser(ctx, user.Id, models.User{Login: want.Login, Password: want.Password}, false)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	assertUser(t, "UpdateUser", updated, want)
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the update", got, want)
+}
+
+func testUpdateNotFound(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	if _, err := s.UpdateUser(ctx, uuid.New(), NewUser("bob"), false); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Fatalf("UpdateUser of a missing user: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the failed update", got, user)
+}
+
+func testUpdateLoginTaken(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	mustInsert(t, s, NewUser("alice"))
+	bob := mustInsert(t, s, NewUser("bob"))
+
+	if _, err := s.UpdateUser(ctx, bob.Id, models.User{Login: "Alice", Password: bob.Password}, false); !errors.Is(err, storageerror.ErrLoginTaken) {
+		t.Fatalf("UpdateUser to a taken login: got %v, want %v", err, storageerror.ErrLoginTaken)
+	}
+
+	got, err := s.GetUserById(ctx, bob.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the failed update", got, bob)
+
+	// Keeping one's own login isn't a conflict.
+	if _, err := s.UpdateUser(ctx, bob.Id, models.User{Login: bob.Login, Password: "new-hash"}, false); err != nil {
+		t.Fatalf("UpdateUser keeping the login: %v", err)
+	}
+}
+
+func testUpdateKeepingLogin(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("al"))
+
+	if _, err := s.UpdateUser(ctx, user.Id, models.User{Login: "bo", Password: "new-hash"}, true); !errors.Is(err, storageerror.ErrLoginChanged) {
+		t.Fatalf("UpdateUser changing a kept login: got %v, want %v", err, storageerror.ErrLoginChanged)
+	}
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the failed update", got, user)
+
+	if _, err := s.UpdateUser(ctx, uuid.New(), models.User{Login: "al", Password: "new-hash"}, true); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Fatalf("UpdateUser of a missing user: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+
+	want := user
+	want.Password = "new-hash"
+
+	updated, err := s.UpdateUser(ctx, user.Id, models.User{Login: "al", Password: "new-hash"}, true)
+	if err != nil {
+		t.Fatalf("UpdateUser keeping the login: %v", err)
+	}
+	assertUser(t, "UpdateUser", updated, want)
+}
+
+func testDelete(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	deleted, err := s.DeleteUser(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	assertUser(t, "DeleteUser", deleted, user)
+
+	if _, err := s.GetUserById(ctx, user.Id); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Errorf("GetUserById after the delete: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+}
+
+func testDeleteNotFound(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	if _, err := s.DeleteUser(ctx, uuid.New()); !errors.Is(err, storageerror.ErrNotFound) {
+		t.Fatalf("DeleteUser of a missing user: got %v, want %v", err, storageerror.ErrNotFound)
+	}
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	assertUser(t, "GetUserById after the failed delete", got, user)
+}
+
+func testGetUsers(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+
+	var all []models.User
+	for i, login := range []string{"carol", "alice", "bob", "alfred"} {
+		user := NewUser(login)
+		user.CreatedAt = user.CreatedAt.Add(time.Duration(i) * time.Second)
+		all = append(all, mustInsert(t, s, user))
+	}
+
+	t.Run("LoginAsc", func(t *testing.T) {
+		got, err := s.GetUsers(ctx, models.UsersFilter{Sort: models.SortLoginAsc})
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, got, "alfred", "alice", "bob", "carol")
+	})
+
+	t.Run("CreatedAtDesc", func(t *testing.T) {
+		got, err := s.GetUsers(ctx, models.UsersFilter{Sort: models.SortCreatedAtDesc})
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, got, "alfred", "bob", "alice", "carol")
+	})
+
+	t.Run("LoginPrefix", func(t *testing.T) {
+		got, err := s.GetUsers(ctx, models.UsersFilter{LoginPrefix: "al", Sort: models.SortLoginAsc})
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, got, "alfred", "alice")
+	})
+
+	t.Run("CreatedRange", func(t *testing.T) {
+		got, err := s.GetUsers(ctx, models.UsersFilter{
+			CreatedAfter:  all[1].CreatedAt,
+			CreatedBefore: all[3].CreatedAt,
+		})
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, got, "alice", "bob")
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		filter := models.UsersFilter{Sort: models.SortLoginAsc, Limit: 3}
+		first, err := s.GetUsers(ctx, filter)
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, first, "alfred", "alice", "bob")
+
+		last := first[len(first)-1]
+		filter.After = &models.UsersCursor{CreatedAt: last.CreatedAt, Login: last.Login, Id: last.Id}
+		second, err := s.GetUsers(ctx, filter)
+		if err != nil {
+			t.Fatalf("GetUsers: %v", err)
+		}
+		assertLogins(t, second, "carol")
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		var got []models.User
+		err := s.StreamUsers(ctx, models.UsersFilter{Sort: models.SortLoginDesc}, func(user models.User) error {
+			got = append(got, user)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StreamUsers: %v", err)
+		}
+		assertLogins(t, got, "carol", "bob", "alice", "alfred")
+	})
+
+	t.Run("StreamStops", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := s.StreamUsers(ctx, models.UsersFilter{}, func(models.User) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) {
+			t.Errorf("StreamUsers: got %v, want %v", err, stop)
+		}
+		if calls != 1 {
+			t.Errorf("StreamUsers called fn %d times after it failed, want 1", calls)
+		}
+	})
+}
+
+// testCancelledContext checks every method fails with the context error,
+// without touching the data.
+func testCancelledContext(t *testing.T, s storage.IUserStorage) {
+	user := mustInsert(t, s, NewUser("alice"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{"GetUsers", func() error {
+			_, err := s.GetUsers(ctx, models.UsersFilter{})
+			return err
+		}},
+		{"StreamUsers", func() error {
+			return s.StreamUsers(ctx, models.UsersFilter{}, func(models.User) error { return nil })
+		}},
+		{"GetUserById", func() error {
+			_, err := s.GetUserById(ctx, user.Id)
+			return err
+		}},
+		{"GetUserByLogin", func() error {
+			_, err := s.GetUserByLogin(ctx, user.Login)
+			return err
+		}},
+		{"InsertUser", func() error {
+			_, err := s.InsertUser(ctx, NewUser("bob"))
+			return err
+		}},
+		{"UpdateUser", func() error {
+			_, err := s.UpdateUser(ctx, user.Id, models.User{Login: "alice2", Password: "new-hash"}, false)
+			return err
+		}},
+		{"DeleteUser", func() error {
+			_, err := s.DeleteUser(ctx, user.Id)
+			return err
+		}},
+	}
+
+	for _, c := range calls {
+		if err := c.call(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s with a cancelled context: got %v, want %v", c.name, err, context.Canceled)
+		}
+	}
+
+	got, err := s.GetUsers(context.Background(), models.UsersFilter{})
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d users after the cancelled calls, want 1", len(got))
+	}
+	assertUser(t, "GetUsers after the cancelled calls", got[0], user)
+}
+
+const writers = 16
+
+// testConcurrentInserts inserts distinct users from several goroutines, all
+// of them must be stored.
+func testConcurrentInserts(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+
+	errs := make(chan error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.InsertUser(ctx, NewUser(fmt.Sprintf("user%02d", i)))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("InsertUser: %v", err)
+		}
+	}
+
+	got, err := s.GetUsers(ctx, models.UsersFilter{})
+	if err != nil {
+		t.Fatalf("GetUsers: %v", err)
+	}
+	if len(got) != writers {
+		t.Fatalf("got %d users, want %d", len(got), writers)
+	}
+}
+
+// testConcurrentDuplicateInserts races inserts of the same id, exactly one
+// of them must win.
+func testConcurrentDuplicateInserts(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	id := uuid.New()
+
+	errs := make(chan error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user := NewUser(fmt.Sprintf("user%02d", i))
+			user.Id = id
+			_, err := s.InsertUser(ctx, user)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	inserted := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			inserted++
+		case !errors.Is(err, storageerror.ErrAlreadyExists):
+			t.Errorf("InsertUser with a taken id: got %v, want %v", err, storageerror.ErrAlreadyExists)
+		}
+	}
+	if inserted != 1 {
+		t.Fatalf("%d inserts of the same id succeeded, want 1", inserted)
+	}
+}
+
+// testConcurrentUpdates updates one user from several goroutines, the user
+// must end up as one of the writes left it.
+func testConcurrentUpdates(t *testing.T, s storage.IUserStorage) {
+	ctx := context.Background()
+	user := mustInsert(t, s, NewUser("alice"))
+
+	errs := make(chan error, writers)
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			login := fmt.Sprintf("alice%02d", i)
+			_, err := s.UpdateUser(ctx, user.Id, models.User{Login: login, Password: "hash-of-" + login}, false)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("UpdateUser: %v", err)
+		}
+	}
+
+	got, err := s.GetUserById(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	}
+	if got.Password != "hash-of-"+got.Login || !strings.HasPrefix(got.Login, "alice") || got.Login == "alice" {
+		t.Fatalf("got %+v, want the login and password of a single update", got)
+	}
+}
+
+func mustInsert(t *testing.T, s storage.IUserStorage, user models.User) models.User {
+	t.Helper()
+
+	inserted, err := s.InsertUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("InsertUser(%s): %v", user.Login, err)
+	}
+	return inserted
+}
+
+func assertUser(t *testing.T, call string, got, want models.User) {
+	t.Helper()
+
+	if got.Id != want.Id || got.Login != want.Login || got.Password != want.Password || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("%s: got %+v, want %+v", call, got, want)
+	}
+}
+
+func assertLogins(t *testing.T, users []models.User, want ...string) {
+	t.Helper()
+
+	got := make([]string, 0, len(users))
+	for _, user := range users {
+		got = append(got, user.Login)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got logins %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got logins %v, want %v", got, want)
+		}
+	}
+}
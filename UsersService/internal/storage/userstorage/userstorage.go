@@ -6,15 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"strings"
 	"users-service/internal/domain/models"
 	storageerror "users-service/internal/storage"
+	"users-service/internal/storage/dbconn"
+	"users-service/internal/storage/pgerr"
+	"users-service/pkg/config"
 	"users-service/pkg/logger/sl"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
-	"github.com/pressly/goose/v3"
+	_ "github.com/lib/pq" // registers the "postgres" driver
 )
 
 type PsqlStorage struct {
@@ -24,7 +25,15 @@ type PsqlStorage struct {
 
 const UsersTableName = "Users"
 
-func New(log *slog.Logger, connStr string) *PsqlStorage {
+// LoginIndexName is the unique index on the normalized login, see
+// models.NormalizeLogin.
+const LoginIndexName = "users_login_normalized_idx"
+
+const userColumns = "id, login, password, created_at"
+
+// New opens a connection pool sized by cfg and waits for the database to
+// answer pings, see dbconn.Wait.
+func New(log *slog.Logger, connStr string, cfg config.StorageConfig) *PsqlStorage {
 	const op = "psql.New"
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
@@ -32,9 +41,14 @@ func New(log *slog.Logger, connStr string) *PsqlStorage {
 		panic(err)
 	}
 
-	wd, _ := os.Getwd()
-	migrationPath := filepath.Join(wd, "app", "migrations")
-	if err := applyMigrations(db, migrationPath); err != nil {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := dbconn.Wait(context.Background(), log, db.PingContext, cfg); err != nil {
+		log.With(slog.String("op", op)).Error("Database is unreachable", sl.Err(err))
+		db.Close()
 		panic(err)
 	}
 
@@ -44,79 +58,217 @@ func New(log *slog.Logger, connStr string) *PsqlStorage {
 	}
 }
 
-func applyMigrations(db *sql.DB, migrationsPath string) error {
-	return goose.Up(db, migrationsPath)
-}
-
 func (p *PsqlStorage) Close() {
 	p.DB.Close()
 }
 
-// GetUsers implements storage.IUserStorage.
-func (p *PsqlStorage) GetUsers(ctx context.Context) ([]models.User, error) {
+// GetUsers implements storage.IUserStorage. Pages are selected with keyset
+// pagination: rows strictly after filter.After in the sort order.
+func (p *PsqlStorage) GetUsers(ctx context.Context, filter models.UsersFilter) ([]models.User, error) {
 	const op = "storage.user.GetUsers"
 	log := p.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return nil, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	rows, err := p.DB.QueryContext(ctx, `
-		SELECT * FROM `+UsersTableName+`;
-	`)
+	query, args := UsersQuery(filter)
+	rows, err := p.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Error("error scanning rows", sl.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
-	var users = make([]models.User, 0, 5)
+	// A page cut short would look like the last one, so any error fails
+	// the whole page.
+	var users = make([]models.User, 0, filter.Limit)
 	var buffUser models.User
 	for rows.Next() {
-		err := rows.Scan(&buffUser.Id, &buffUser.Login, &buffUser.Password)
+		err := rows.Scan(&buffUser.Id, &buffUser.Login, &buffUser.Password, &buffUser.CreatedAt)
 		if err != nil {
-			log.Warn("cannot scan row", sl.Err(err))
-			continue
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 
 		users = append(users, buffUser)
 	}
 
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return users, nil
 }
 
+// StreamUsers implements storage.IUserStorage. Users are read from the result
+// set one by one and passed to fn, so the table is never buffered in memory.
+// An error returned by fn stops the iteration.
+func (p *PsqlStorage) StreamUsers(ctx context.Context, filter models.UsersFilter, fn func(models.User) error) error {
+	const op = "storage.user.StreamUsers"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	query, args := UsersQuery(filter)
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.ErrorContext(ctx, "cannot fetch users", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.Id, &user.Login, &user.Password, &user.CreatedAt); err != nil {
+			log.ErrorContext(ctx, "cannot scan row", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(user); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		log.ErrorContext(ctx, "error iterating rows", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UsersQuery builds the select of a page of users described by the filter.
+func UsersQuery(filter models.UsersFilter) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.LoginPrefix != "" {
+		conditions = append(conditions, `login LIKE `+arg(likePrefix(filter.LoginPrefix))+` ESCAPE '\'`)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		conditions = append(conditions, "created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		conditions = append(conditions, "created_at < "+arg(filter.CreatedBefore))
+	}
+
+	var key, direction, comparison string
+	switch filter.Sort {
+	case models.SortCreatedAtDesc:
+		key, direction, comparison = "created_at", "DESC", "<"
+	case models.SortLoginAsc:
+		key, direction, comparison = "login", "ASC", ">"
+	case models.SortLoginDesc:
+		key, direction, comparison = "login", "DESC", "<"
+	default:
+		key, direction, comparison = "created_at", "ASC", ">"
+	}
+
+	if filter.After != nil {
+		var after any = filter.After.CreatedAt
+		if key == "login" {
+			after = filter.After.Login
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s, %s)", key, comparison, arg(after), arg(filter.After.Id)))
+	}
+
+	query := `SELECT ` + userColumns + ` FROM ` + UsersTableName
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", key, direction, direction)
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	return query, args
+}
+
+// likePrefix escapes LIKE wildcards in the prefix.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
 // GetUserById implements storage.IUserStorage.
 func (p *PsqlStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.User, error) {
 	const op = "storage.user.GetUserById"
 	log := p.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	var user models.User
 	err := p.DB.QueryRowContext(ctx, `
-		SELECT * FROM `+UsersTableName+`
+		SELECT `+userColumns+` FROM `+UsersTableName+`
 		WHERE id=$1
-	`, id).Scan(&user.Id, &user.Login, &user.Password)
+	`, id).Scan(&user.Id, &user.Login, &user.Password, &user.CreatedAt)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Warn("user not found", sl.Err(err))
+			log.WarnContext(ctx, "user not found", sl.Err(err))
 			return models.User{}, storageerror.ErrNotFound
 		}
 
-		log.Error("cannot scan user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetUserByLogin implements storage.IUserStorage.
+func (p *PsqlStorage) GetUserByLogin(ctx context.Context, login string) (models.User, error) {
+	const op = "storage.user.GetUserByLogin"
+	log := p.log.With(
+		"op", op,
+	)
+
+	select {
+	case <-ctx.Done():
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
+		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
+	default:
+	}
+
+	var user models.User
+	err := p.DB.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM `+UsersTableName+`
+		WHERE login=$1
+	`, login).Scan(&user.Id, &user.Login, &user.Password, &user.CreatedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		log.ErrorContext(ctx, "cannot scan user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -127,34 +279,34 @@ func (p *PsqlStorage) GetUserById(ctx context.Context, id uuid.UUID) (models.Use
 func (p *PsqlStorage) InsertUser(ctx context.Context, user models.User) (models.User, error) {
 	const op = "storage.user.InsertUser"
 	log := p.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
 	_, err := p.DB.ExecContext(ctx, `
-		INSERT INTO `+UsersTableName+`(id, login, password)
-		VALUES($1, $2, $3);
-	`, user.Id, user.Login, user.Password)
+		INSERT INTO `+UsersTableName+`(id, login, password, created_at)
+		VALUES($1, $2, $3, $4);
+	`, user.Id, user.Login, user.Password, user.CreatedAt)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
-			log.Warn("user already exists", sl.Err(err))
-			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrAlreadyExists)
+		if conflict := Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "user already exists", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
 		}
 
-		log.Error("cannot insert user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot insert user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return user, nil
 }
 
-func (p *PsqlStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User) (models.User, error) {
+func (p *PsqlStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.User, keepLogin bool) (models.User, error) {
 	const op = "storage.user.UpdateUser"
 	log := p.log.With(
 		"op", op,
@@ -162,45 +314,56 @@ func (p *PsqlStorage) UpdateUser(ctx context.Context, id uuid.UUID, user models.
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
 
-	result, err := p.DB.ExecContext(ctx, `
+	var updated models.User
+	err := p.DB.QueryRowContext(ctx, `
 		UPDATE `+UsersTableName+`
 		SET login=$1, password=$2
-		WHERE id=$3
-	`, user.Login, user.Password, id)
+		WHERE id=$3 AND (NOT $4::boolean OR login=$1)
+		RETURNING `+userColumns+`
+	`, user.Login, user.Password, id, keepLogin).Scan(&updated.Id, &updated.Login, &updated.Password, &updated.CreatedAt)
 	if err != nil {
-		log.Error("fialed to update user", sl.Err(err))
-		return user, fmt.Errorf("%s: %w", op, err)
-	}
+		if errors.Is(err, sql.ErrNoRows) {
+			// Either the user is missing or its login isn't the one given.
+			if keepLogin {
+				if _, err := p.GetUserById(ctx, id); err != nil {
+					return models.User{}, fmt.Errorf("%s: %w", op, err)
+				}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Error("Error get rows affected", sl.Err(err))
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
+				log.WarnContext(ctx, "login doesn't match the stored one")
+				return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrLoginChanged)
+			}
+
+			log.WarnContext(ctx, "user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		}
+
+		if conflict := Conflict(err); conflict != nil {
+			log.WarnContext(ctx, "login is taken", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, conflict)
+		}
 
-	if rowsAffected == 0 {
-		log.Error("Zero rows affected")
-		return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
+		log.ErrorContext(ctx, "fialed to update user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return user, nil
+	return updated, nil
 }
 
 // DeleteUser implements storage.IUserStorage.
 func (p *PsqlStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User, error) {
 	const op = "storage.user.DeleteUser"
 	log := p.log.With(
-		op, "op",
+		"op", op,
 	)
 
 	select {
 	case <-ctx.Done():
-		log.Error("request time out", sl.Err(ctx.Err()))
+		log.ErrorContext(ctx, "request time out", sl.Err(ctx.Err()))
 		return models.User{}, fmt.Errorf("%s: %w", op, ctx.Err())
 	default:
 	}
@@ -208,11 +371,11 @@ func (p *PsqlStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User
 	user, err := p.GetUserById(ctx, id)
 	if err != nil {
 		if errors.Is(err, storageerror.ErrNotFound) {
-			log.Warn("user doesn't exists", sl.Err(err))
+			log.WarnContext(ctx, "user doesn't exists", sl.Err(err))
 			return models.User{}, fmt.Errorf("%s: %w", op, storageerror.ErrNotFound)
 		}
 
-		log.Error("cannot delete user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -221,9 +384,24 @@ func (p *PsqlStorage) DeleteUser(ctx context.Context, id uuid.UUID) (models.User
 		WHERE id=$1;
 	`, id)
 	if err != nil {
-		log.Error("cannot delete user", sl.Err(err))
+		log.ErrorContext(ctx, "cannot delete user", sl.Err(err))
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return user, nil
 }
+
+// Conflict translates a unique violation of the users table into
+// storageerror.ErrLoginTaken or storageerror.ErrAlreadyExists, it returns
+// nil for any other error. Shared by the storages of every SQL database.
+func Conflict(err error) error {
+	if pgerr.Code(err) != pgerr.UniqueViolation {
+		return nil
+	}
+
+	if pgerr.Constraint(err) == LoginIndexName {
+		return storageerror.ErrLoginTaken
+	}
+
+	return storageerror.ErrAlreadyExists
+}
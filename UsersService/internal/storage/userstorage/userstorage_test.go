@@ -0,0 +1,22 @@
+package userstorage_test
+
+import (
+	"testing"
+	"time"
+	"users-service/internal/domain/interfaces/storage"
+	"users-service/internal/storage/storagetest"
+	"users-service/internal/storage/userstorage"
+	"users-service/pkg/config"
+)
+
+func TestPsqlStorage(t *testing.T) {
+	storagetest.RunUserStorage(t, func(t *testing.T) storage.IUserStorage {
+		s := userstorage.New(storagetest.Logger(), storagetest.PostgresDSN(t), config.StorageConfig{
+			ConnectTimeout: 5 * time.Second,
+			InitialBackoff: 100 * time.Millisecond,
+			MaxBackoff:     time.Second,
+		})
+		t.Cleanup(s.Close)
+		return s
+	})
+}
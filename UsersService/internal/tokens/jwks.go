@@ -0,0 +1,38 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"users-service/internal/domain/models"
+)
+
+// JWKS returns the public parts of all configured keys.
+func (i *Issuer) JWKS() []models.JWK {
+	jwks := make([]models.JWK, 0, len(i.keys))
+	for _, k := range i.keys {
+		jwk := models.JWK{
+			Kid: k.id,
+			Alg: k.algorithm,
+			Use: "sig",
+		}
+
+		switch public := k.private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		default:
+			continue
+		}
+
+		jwks = append(jwks, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,201 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"users-service/internal/domain/models"
+	"users-service/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+
+	TokenType = "Bearer"
+)
+
+var (
+	ErrNoSigningKey         = errors.New("signing key is not configured")
+	ErrUnsupportedKey       = errors.New("unsupported key type")
+	ErrAlgorithmKeyMismatch = errors.New("key doesn't match algorithm")
+	ErrDuplicateKeyId       = errors.New("duplicate key id")
+	ErrInvalidTTL           = errors.New("token ttl must be positive")
+)
+
+// Claims are the claims carried by access tokens.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+type key struct {
+	id        string
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+}
+
+// Issuer signs access tokens with the active key. All configured keys are
+// published in the JWKS so tokens signed with a key that is being rotated
+// out stay verifiable until they expire.
+type Issuer struct {
+	issuer  string
+	ttl     time.Duration
+	signing *key
+	keys    []*key
+}
+
+func New(cfg config.TokensConfig, ttl time.Duration) (*Issuer, error) {
+	const op = "tokens.New"
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidTTL, ttl)
+	}
+
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoSigningKey)
+	}
+
+	issuer := &Issuer{
+		issuer: cfg.Issuer,
+		ttl:    ttl,
+		keys:   make([]*key, 0, len(cfg.Keys)),
+	}
+
+	ids := make(map[string]bool, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		// The JWKS is looked up by key id, two keys under one id would make
+		// verifiers pick either.
+		if ids[keyCfg.Id] {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrDuplicateKeyId, keyCfg.Id)
+		}
+		ids[keyCfg.Id] = true
+
+		k, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, keyCfg.Id, err)
+		}
+
+		issuer.keys = append(issuer.keys, k)
+		if k.id == cfg.SigningKey {
+			issuer.signing = k
+		}
+	}
+
+	if issuer.signing == nil {
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrNoSigningKey, cfg.SigningKey)
+	}
+
+	return issuer, nil
+}
+
+// NewEphemeral creates an issuer with a single Ed25519 key generated in memory.
+// Tokens it signs become unverifiable after restart, so it's meant for local runs only.
+func NewEphemeral(issuerName string, ttl time.Duration) (*Issuer, error) {
+	const op = "tokens.NewEphemeral"
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidTTL, ttl)
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	k := &key{
+		id:        uuid.NewString(),
+		algorithm: AlgorithmEdDSA,
+		method:    jwt.SigningMethodEdDSA,
+		private:   private,
+	}
+
+	return &Issuer{
+		issuer:  issuerName,
+		ttl:     ttl,
+		signing: k,
+		keys:    []*key{k},
+	}, nil
+}
+
+// Issue signs an access token for the user.
+func (i *Issuer) Issue(userId uuid.UUID, roles []string) (models.Tokens, error) {
+	const op = "tokens.Issue"
+
+	now := time.Now()
+	expiresAt := now.Add(i.ttl)
+
+	token := jwt.NewWithClaims(i.signing.method, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    i.issuer,
+			Subject:   userId.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Roles: roles,
+	})
+	token.Header["kid"] = i.signing.id
+
+	signed, err := token.SignedString(i.signing.private)
+	if err != nil {
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.Tokens{
+		AccessToken: signed,
+		TokenType:   TokenType,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+func loadKey(cfg config.KeyConfig) (*key, error) {
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := &key{id: cfg.Id}
+	switch private := parsed.(type) {
+	case ed25519.PrivateKey:
+		k.algorithm, k.method, k.private = AlgorithmEdDSA, jwt.SigningMethodEdDSA, private
+	case *rsa.PrivateKey:
+		k.algorithm, k.method, k.private = AlgorithmRS256, jwt.SigningMethodRS256, private
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, parsed)
+	}
+
+	if cfg.Algorithm != "" && cfg.Algorithm != k.algorithm {
+		return nil, fmt.Errorf("%w: %s key configured as %s", ErrAlgorithmKeyMismatch, k.algorithm, cfg.Algorithm)
+	}
+
+	return k, nil
+}
@@ -0,0 +1,373 @@
+package tokens_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+	"users-service/internal/domain/models"
+	"users-service/internal/tokens"
+	"users-service/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const issuerName = "users-service"
+
+var (
+	ed25519Key ed25519.PrivateKey
+	rsaKey     *rsa.PrivateKey
+)
+
+func TestMain(m *testing.M) {
+	var err error
+
+	if _, ed25519Key, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		panic(err)
+	}
+	if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// writeKey stores key PEM encoded in a temporary file and returns its path.
+func writeKey(t *testing.T, key any, pkcs1 bool) string {
+	t.Helper()
+
+	block := &pem.Block{Type: "PRIVATE KEY"}
+	if pkcs1 {
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key.(*rsa.PrivateKey))}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.Bytes = der
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// publicKey decodes a JWK the way verifiers do.
+func publicKey(t *testing.T, jwk models.JWK) any {
+	t.Helper()
+
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("JWK %q: %v", jwk.Kid, err)
+		}
+		return b
+	}
+
+	switch jwk.Kty {
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+		}
+	default:
+		t.Fatalf("JWK %q: unexpected kty %q", jwk.Kid, jwk.Kty)
+		return nil
+	}
+}
+
+// verify checks token against the JWKS like the API does.
+func verify(t *testing.T, jwks []models.JWK, token string) (*tokens.Claims, *jwt.Token, error) {
+	t.Helper()
+
+	var claims tokens.Claims
+	parsed, err := jwt.NewParser(
+		jwt.WithValidMethods([]string{tokens.AlgorithmEdDSA, tokens.AlgorithmRS256}),
+		jwt.WithIssuer(issuerName),
+		jwt.WithExpirationRequired(),
+	).ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, jwk := range jwks {
+			if jwk.Kid == kid {
+				return publicKey(t, jwk), nil
+			}
+		}
+		return nil, errors.New("unknown kid")
+	})
+
+	return &claims, parsed, err
+}
+
+func TestIssueRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     any
+		pkcs1   bool
+		wantAlg string
+	}{
+		{"Ed25519", ed25519Key, false, tokens.AlgorithmEdDSA},
+		{"RSA PKCS8", rsaKey, false, tokens.AlgorithmRS256},
+		{"RSA PKCS1", rsaKey, true, tokens.AlgorithmRS256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, err := tokens.New(config.TokensConfig{
+				Issuer:     issuerName,
+				SigningKey: "key",
+				Keys:       []config.KeyConfig{{Id: "key", Path: writeKey(t, tt.key, tt.pkcs1)}},
+			}, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			userId := uuid.New()
+			issued, err := issuer.Issue(userId, []string{"admin"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if issued.TokenType != tokens.TokenType {
+				t.Errorf("TokenType = %q, want %q", issued.TokenType, tokens.TokenType)
+			}
+			if ttl := time.Until(issued.ExpiresAt); ttl <= 0 || ttl > time.Minute {
+				t.Errorf("ExpiresAt in %v, want within a minute", ttl)
+			}
+
+			claims, token, err := verify(t, issuer.JWKS(), issued.AccessToken)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if token.Method.Alg() != tt.wantAlg || token.Header["kid"] != "key" {
+				t.Errorf("header = %v, want alg %s and kid key", token.Header, tt.wantAlg)
+			}
+			if claims.Subject != userId.String() || !slices.Equal(claims.Roles, []string{"admin"}) {
+				t.Errorf("claims = %+v, want subject %v with role admin", claims, userId)
+			}
+			if !claims.ExpiresAt.Time.Equal(issued.ExpiresAt.Truncate(time.Second)) {
+				t.Errorf("exp = %v, want %v", claims.ExpiresAt.Time, issued.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestIssueEphemeral(t *testing.T) {
+	issuer, err := tokens.NewEphemeral(issuerName, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := issuer.Issue(uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := verify(t, issuer.JWKS(), issued.AccessToken); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+
+	other, err := tokens.NewEphemeral(issuerName, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := verify(t, other.JWKS(), issued.AccessToken); err == nil {
+		t.Error("token verified against the keys of another ephemeral issuer")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldPath, newPath := writeKey(t, rsaKey, false), writeKey(t, ed25519Key, false)
+
+	before, err := tokens.New(config.TokensConfig{
+		Issuer:     issuerName,
+		SigningKey: "old",
+		Keys:       []config.KeyConfig{{Id: "old", Path: oldPath}},
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key is added and made active, the old one is kept until the
+	// tokens it signed expire.
+	during, err := tokens.New(config.TokensConfig{
+		Issuer:     issuerName,
+		SigningKey: "new",
+		Keys:       []config.KeyConfig{{Id: "old", Path: oldPath}, {Id: "new", Path: newPath}},
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := tokens.New(config.TokensConfig{
+		Issuer:     issuerName,
+		SigningKey: "new",
+		Keys:       []config.KeyConfig{{Id: "new", Path: newPath}},
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var kids []string
+	for _, jwk := range during.JWKS() {
+		kids = append(kids, jwk.Kid)
+	}
+	if !slices.Equal(kids, []string{"old", "new"}) {
+		t.Errorf("JWKS kids = %v, want [old new]", kids)
+	}
+
+	oldToken, err := before.Issue(uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := during.Issue(uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, token, err := verify(t, during.JWKS(), newToken.AccessToken)
+	if err != nil {
+		t.Fatalf("verify(new token): %v", err)
+	}
+	if token.Header["kid"] != "new" {
+		t.Errorf("kid = %v, want the active key", token.Header["kid"])
+	}
+
+	if _, _, err := verify(t, during.JWKS(), oldToken.AccessToken); err != nil {
+		t.Errorf("verify(old token) during rotation: %v", err)
+	}
+	if _, _, err := verify(t, after.JWKS(), oldToken.AccessToken); err == nil {
+		t.Error("old token verified after its key was removed")
+	}
+	if _, _, err := verify(t, after.JWKS(), newToken.AccessToken); err != nil {
+		t.Errorf("verify(new token) after rotation: %v", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	issuer, err := tokens.New(config.TokensConfig{
+		Issuer:     issuerName,
+		SigningKey: "ed",
+		Keys: []config.KeyConfig{
+			{Id: "ed", Path: writeKey(t, ed25519Key, false)},
+			{Id: "rsa", Path: writeKey(t, rsaKey, true)},
+		},
+	}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := issuer.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("JWKS = %+v, want 2 keys", jwks)
+	}
+
+	ed, rsaJWK := jwks[0], jwks[1]
+	if ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != tokens.AlgorithmEdDSA || ed.Use != "sig" || ed.N != "" || ed.E != "" {
+		t.Errorf("Ed25519 JWK = %+v", ed)
+	}
+	if got := publicKey(t, ed); !ed25519Key.Public().(ed25519.PublicKey).Equal(got) {
+		t.Error("Ed25519 JWK doesn't decode to the public key")
+	}
+
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != tokens.AlgorithmRS256 || rsaJWK.Use != "sig" || rsaJWK.Crv != "" || rsaJWK.X != "" {
+		t.Errorf("RSA JWK = %+v", rsaJWK)
+	}
+	// 65537, without leading zero bytes, as RFC 7518 requires.
+	if rsaJWK.E != "AQAB" {
+		t.Errorf("RSA JWK e = %q, want AQAB", rsaJWK.E)
+	}
+	if got := publicKey(t, rsaJWK); !rsaKey.PublicKey.Equal(got) {
+		t.Error("RSA JWK doesn't decode to the public key")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.TokensConfig
+		wantErr error
+	}{
+		{"no keys", config.TokensConfig{}, tokens.ErrNoSigningKey},
+		{
+			"unknown signing key",
+			config.TokensConfig{SigningKey: "missing", Keys: []config.KeyConfig{{Id: "ed", Path: writeKey(t, ed25519Key, false)}}},
+			tokens.ErrNoSigningKey,
+		},
+		{
+			"algorithm mismatch",
+			config.TokensConfig{SigningKey: "ed", Keys: []config.KeyConfig{{Id: "ed", Algorithm: tokens.AlgorithmRS256, Path: writeKey(t, ed25519Key, false)}}},
+			tokens.ErrAlgorithmKeyMismatch,
+		},
+		{
+			"unsupported key",
+			config.TokensConfig{SigningKey: "ec", Keys: []config.KeyConfig{{Id: "ec", Path: writeKey(t, ecdsaKey, false)}}},
+			tokens.ErrUnsupportedKey,
+		},
+		{
+			"duplicate key id",
+			config.TokensConfig{SigningKey: "key", Keys: []config.KeyConfig{
+				{Id: "key", Path: writeKey(t, ed25519Key, false)},
+				{Id: "key", Path: writeKey(t, rsaKey, false)},
+			}},
+			tokens.ErrDuplicateKeyId,
+		},
+		{"not PEM", config.TokensConfig{SigningKey: "bad", Keys: []config.KeyConfig{{Id: "bad", Path: notPEM}}}, nil},
+		{"missing file", config.TokensConfig{SigningKey: "bad", Keys: []config.KeyConfig{{Id: "bad", Path: filepath.Join(t.TempDir(), "missing.pem")}}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tokens.New(tt.cfg, time.Minute)
+			if err == nil {
+				t.Fatal("New succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRejectsTTL(t *testing.T) {
+	cfg := config.TokensConfig{
+		Issuer:     issuerName,
+		SigningKey: "key",
+		Keys:       []config.KeyConfig{{Id: "key", Path: writeKey(t, ed25519Key, false)}},
+	}
+
+	for _, ttl := range []time.Duration{0, -time.Minute} {
+		if _, err := tokens.New(cfg, ttl); !errors.Is(err, tokens.ErrInvalidTTL) {
+			t.Errorf("New(ttl = %v) = %v, want %v", ttl, err, tokens.ErrInvalidTTL)
+		}
+		if _, err := tokens.NewEphemeral(issuerName, ttl); !errors.Is(err, tokens.ErrInvalidTTL) {
+			t.Errorf("NewEphemeral(ttl = %v) = %v, want %v", ttl, err, tokens.ErrInvalidTTL)
+		}
+	}
+}
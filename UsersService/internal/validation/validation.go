@@ -0,0 +1,165 @@
+// Package validation checks the input of the gRPC handlers before it
+// reaches the services. A Validator collects every violation of a request
+// instead of stopping at the first one, so clients can fix all their fields
+// in one go.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"users-service/internal/domain/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LoginMinLength = 3
+	LoginMaxLength = 32
+
+	PasswordMinLength = 8
+	// PasswordMaxLength is in bytes: bcrypt ignores everything past 72.
+	PasswordMaxLength = 72
+)
+
+// Violation is a field of a request and what is wrong with it.
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Error lists the violations of a request.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	fields := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		fields = append(fields, violation.Field+": "+violation.Description)
+	}
+
+	return "invalid " + strings.Join(fields, ", ")
+}
+
+// Validator collects violations. The zero value is ready to use.
+type Validator struct {
+	violations []Violation
+}
+
+// Err returns an *Error with the collected violations, or nil if there are
+// none.
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &Error{Violations: v.violations}
+}
+
+// Add records a violation of field.
+func (v *Validator) Add(field, description string) {
+	v.violations = append(v.violations, Violation{
+		Field:       field,
+		Description: description,
+	})
+}
+
+// Required checks that value is not empty.
+func (v *Validator) Required(field, value string) bool {
+	if value == "" {
+		v.Add(field, "is required")
+		return false
+	}
+
+	return true
+}
+
+// Id parses a required uuid. The nil uuid is what clients send when they
+// leave the id out, so it counts as missing.
+func (v *Validator) Id(field, value string) uuid.UUID {
+	if !v.Required(field, value) {
+		return uuid.Nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		v.Add(field, "must be a uuid")
+		return uuid.Nil
+	}
+
+	if id == uuid.Nil {
+		v.Add(field, "is required")
+	}
+
+	return id
+}
+
+// Login checks a login as it will be stored, that is normalized: between
+// LoginMinLength and LoginMaxLength latin letters, digits, dots, dashes and
+// underscores, starting with a letter or a digit.
+func (v *Validator) Login(field, login string) {
+	if !v.Required(field, login) {
+		return
+	}
+
+	login = models.NormalizeLogin(login)
+	if n := utf8.RuneCountInString(login); n < LoginMinLength || n > LoginMaxLength {
+		v.Add(field, fmt.Sprintf("must be %d to %d characters long", LoginMinLength, LoginMaxLength))
+		return
+	}
+
+	for i, r := range login {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case i > 0 && (r == '.' || r == '-' || r == '_'):
+		default:
+			v.Add(field, "must contain only latin letters, digits, dots, dashes and underscores, and start with a letter or a digit")
+			return
+		}
+	}
+}
+
+// LoginChange checks the new login of an existing user like Login, except
+// that a login failing the policy is let through: users named before the
+// policy must still be able to update the rest of their account, as long as
+// they keep their login. Only the update itself can tell that atomically, so
+// LoginChange reports whether it must require the login to be unchanged.
+func (v *Validator) LoginChange(field, login string) (keepLogin bool) {
+	if !v.Required(field, login) {
+		return false
+	}
+
+	var policy Validator
+	policy.Login(field, login)
+
+	return policy.Err() != nil
+}
+
+// TimeRange checks that after is before before, when both are set.
+func (v *Validator) TimeRange(afterField, beforeField string, after, before time.Time) {
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		v.Add(afterField, "must be before "+beforeField)
+	}
+}
+
+// Password checks a new password against the password policy: between
+// PasswordMinLength characters and PasswordMaxLength bytes, not only
+// whitespace.
+func (v *Validator) Password(field, password string) {
+	if !v.Required(field, password) {
+		return
+	}
+
+	switch {
+	case utf8.RuneCountInString(password) < PasswordMinLength:
+		v.Add(field, fmt.Sprintf("must be at least %d characters long", PasswordMinLength))
+	case len(password) > PasswordMaxLength:
+		v.Add(field, fmt.Sprintf("must be at most %d bytes long", PasswordMaxLength))
+	case strings.TrimSpace(password) == "":
+		v.Add(field, "must not be blank")
+	case !utf8.ValidString(password):
+		v.Add(field, "must be valid UTF-8")
+	}
+}
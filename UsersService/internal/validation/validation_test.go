@@ -0,0 +1,202 @@
+package validation_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"users-service/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+// violations returns the violations collected by v, or nil.
+func violations(t *testing.T, v *validation.Validator) []validation.Violation {
+	t.Helper()
+
+	err := v.Err()
+	if err == nil {
+		return nil
+	}
+
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		t.Fatalf("Err() = %T, want *validation.Error", err)
+	}
+
+	return verr.Violations
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name  string
+		login string
+		valid bool
+	}{
+		{"empty", "", false},
+		{"2 characters", "ab", false},
+		{"3 characters", "abc", true},
+		{"32 characters", strings.Repeat("a", 32), true},
+		{"33 characters", strings.Repeat("a", 33), false},
+		{"upper case", "Alice", true},
+		{"surrounding spaces", "  alice ", true},
+		{"spaces only count once trimmed", "  ab  ", false},
+		{"full width", "Ａｌｉｃｅ", true},
+		{"dots dashes and underscores", "a.b-c_d", true},
+		{"leading dot", ".alice", false},
+		{"leading dash", "-alice", false},
+		{"inner space", "alice smith", false},
+		{"non latin", "алиса", false},
+		{"at sign", "alice@example", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validation.Validator
+			v.Login("login", tt.login)
+
+			got := violations(t, &v)
+			if valid := len(got) == 0; valid != tt.valid {
+				t.Errorf("Login(%q) violations = %v, want valid = %v", tt.login, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestLoginChange(t *testing.T) {
+	tests := []struct {
+		name          string
+		login         string
+		valid         bool
+		wantKeepLogin bool
+	}{
+		{"valid login", "bob", true, false},
+		{"valid once normalized", " BOB ", true, false},
+		{"login failing the policy", "al", true, true},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validation.Validator
+			keepLogin := v.LoginChange("login", tt.login)
+
+			got := violations(t, &v)
+			if valid := len(got) == 0; valid != tt.valid {
+				t.Errorf("LoginChange(%q) violations = %v, want valid = %v", tt.login, got, tt.valid)
+			}
+			if keepLogin != tt.wantKeepLogin {
+				t.Errorf("LoginChange(%q) = %v, want %v", tt.login, keepLogin, tt.wantKeepLogin)
+			}
+		})
+	}
+}
+
+func TestPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"empty", "", false},
+		{"7 characters", "1234567", false},
+		{"8 characters", "12345678", true},
+		{"8 multibyte characters", "пароль12", true},
+		{"72 bytes", strings.Repeat("x", 72), true},
+		{"73 bytes", strings.Repeat("x", 73), false},
+		{"under 72 characters but over 72 bytes", strings.Repeat("я", 37), false},
+		{"10 KB", strings.Repeat("x", 10<<10), false},
+		{"blank", strings.Repeat(" ", 8), false},
+		{"invalid UTF-8", "password\xff", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validation.Validator
+			v.Password("password", tt.password)
+
+			got := violations(t, &v)
+			if valid := len(got) == 0; valid != tt.valid {
+				t.Errorf("Password(%q) violations = %v, want valid = %v", tt.password, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestId(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name  string
+		value string
+		want  uuid.UUID
+		valid bool
+	}{
+		{"empty", "", uuid.Nil, false},
+		{"garbage", "not-a-uuid", uuid.Nil, false},
+		{"nil uuid", uuid.Nil.String(), uuid.Nil, false},
+		{"uuid", id.String(), id, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validation.Validator
+			got := v.Id("id", tt.value)
+
+			if got != tt.want {
+				t.Errorf("Id(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			if valid := len(violations(t, &v)) == 0; valid != tt.valid {
+				t.Errorf("Id(%q) valid = %v, want %v", tt.value, valid, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	early := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		after, before time.Time
+		valid         bool
+	}{
+		{"both unset", time.Time{}, time.Time{}, true},
+		{"only after", late, time.Time{}, true},
+		{"only before", time.Time{}, early, true},
+		{"ordered", early, late, true},
+		{"equal", early, early, false},
+		{"reversed", late, early, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v validation.Validator
+			v.TimeRange("created_after", "created_before", tt.after, tt.before)
+
+			if valid := len(violations(t, &v)) == 0; valid != tt.valid {
+				t.Errorf("TimeRange(%v, %v) valid = %v, want %v", tt.after, tt.before, valid, tt.valid)
+			}
+		})
+	}
+}
+
+func TestErrCollectsEveryViolation(t *testing.T) {
+	var v validation.Validator
+	if err := v.Err(); err != nil {
+		t.Fatalf("Err() of an empty validator = %v, want nil", err)
+	}
+
+	v.Id("id", "")
+	v.Login("login", "a")
+	v.Password("password", "short")
+
+	var fields []string
+	for _, violation := range violations(t, &v) {
+		fields = append(fields, violation.Field)
+	}
+	if got, want := strings.Join(fields, ","), "id,login,password"; got != want {
+		t.Errorf("violated fields = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE Users ALTER COLUMN password TYPE VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- Irreversible: the column holds hashes longer than 50 characters from now
+-- on, narrowing it back would fail. Rolling back leaves it as is.
+SELECT 1;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS Roles(
+    id UUID PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS Permissions(
+    id UUID PRIMARY KEY,
+    name VARCHAR(128) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS Role_Permissions(
+    role_id UUID NOT NULL REFERENCES Roles(id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES Permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS User_Roles(
+    user_id UUID NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    role_id UUID NOT NULL REFERENCES Roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO Roles(id, name, description)
+VALUES (gen_random_uuid(), 'admin', 'Full access to users, sessions and roles');
+
+INSERT INTO Permissions(id, name)
+VALUES
+    (gen_random_uuid(), 'users:read'),
+    (gen_random_uuid(), 'users:write'),
+    (gen_random_uuid(), 'users:delete'),
+    (gen_random_uuid(), 'sessions:manage'),
+    (gen_random_uuid(), 'roles:manage');
+
+INSERT INTO Role_Permissions(role_id, permission_id)
+SELECT r.id, p.id FROM Roles r CROSS JOIN Permissions p
+WHERE r.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS User_Roles;
+DROP TABLE IF EXISTS Role_Permissions;
+DROP TABLE IF EXISTS Permissions;
+DROP TABLE IF EXISTS Roles;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON Users(created_at, id);
+CREATE INDEX IF NOT EXISTS users_login_id_idx ON Users(login text_pattern_ops, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_login_id_idx;
+DROP INDEX IF EXISTS users_created_at_id_idx;
+ALTER TABLE Users DROP COLUMN IF EXISTS created_at;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- UsersService stores logins normalized (NFKC, trimmed, lower-cased), see
+-- models.NormalizeLogin; existing ones are normalized here. The index fails
+-- to build if two of them collide, one of the users has to be renamed first.
+--
+-- strings.TrimSpace trims all of Unicode's White_Space, btrim only spaces:
+-- the regex trims [[:space:]] plus the White_Space characters NFKC doesn't
+-- turn into plain spaces. lower() folds non-ASCII letters only if the
+-- database's LC_CTYPE isn't C or POSIX; with those, logins with non-ASCII
+-- upper case letters must be fixed by hand before migrating.
+UPDATE Users SET login = lower(regexp_replace(
+	normalize(login, NFKC),
+	'^[[:space:]\u0085\u1680\u2028\u2029]+|[[:space:]\u0085\u1680\u2028\u2029]+$', '', 'g'
+));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_normalized_idx ON Users(lower(regexp_replace(
+	normalize(login, NFKC),
+	'^[[:space:]\u0085\u1680\u2028\u2029]+|[[:space:]\u0085\u1680\u2028\u2029]+$', '', 'g'
+)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_login_normalized_idx;
+-- +goose StatementEnd
@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"users-service/pkg/config"
+	"users-service/pkg/hasher"
+
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+// legacyPasswordsHasher uses the default argon2id parameters of
+// config.Argon2Config: the configured hasher isn't known to migrations, and
+// hasher.Compare accepts any argon2id hash whatever is configured.
+var legacyPasswordsHasher = config.HasherConfig{
+	Algorithm: hasher.AlgorithmArgon2id,
+	Argon2: config.Argon2Config{
+		Time:       1,
+		Memory:     64 * 1024,
+		Threads:    4,
+		KeyLength:  32,
+		SaltLength: 16,
+	},
+}
+
+// hashPlaintextPasswords hashes the passwords stored in plaintext before
+// 20250415120000_hash_user_password, which hasher.Compare rejects as
+// ErrInvalidHash, so those users keep their passwords. It is written in Go
+// to hash them the way the service does, without a database extension.
+// Irreversible: rolling it back leaves the hashes in place.
+func hashPlaintextPasswords() *goose.Migration {
+	m := goose.NewGoMigration(20250520120000, &goose.GoFunc{RunTx: upHashPlaintextPasswords}, nil)
+	m.Source = "20250520120000_hash_plaintext_passwords.go"
+
+	return m
+}
+
+func upHashPlaintextPasswords(ctx context.Context, tx *sql.Tx) error {
+	h, err := hasher.New(legacyPasswordsHasher)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, password FROM Users
+		WHERE password NOT LIKE '$argon2id$%'
+		  AND password NOT LIKE '$2a$%'
+		  AND password NOT LIKE '$2b$%'
+		  AND password NOT LIKE '$2y$%'
+	`)
+	if err != nil {
+		return err
+	}
+
+	// The rows are read up front, the connection can't run the updates while
+	// they are open.
+	passwords := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var (
+			id       uuid.UUID
+			password string
+		)
+		if err := rows.Scan(&id, &password); err != nil {
+			rows.Close()
+			return err
+		}
+
+		passwords[id] = password
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, password := range passwords {
+		hash, err := h.Hash(password)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", id, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE Users SET password=$1 WHERE id=$2`, hash, id); err != nil {
+			return fmt.Errorf("user %s: %w", id, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package migrations embeds the goose migrations of the database schema, so
+// the binary can migrate the database wherever it runs. SQLite has a set of
+// its own in the sqlite directory.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/pressly/goose/v3"
+)
+
+//go:embed *.sql sqlite/*.sql
+var FS embed.FS
+
+// SQLiteDir is the directory of the SQLite migrations in FS.
+const SQLiteDir = "sqlite"
+
+// Postgres returns the Postgres migrations written in Go, to be registered
+// with goose next to the SQL ones in FS.
+func Postgres() []*goose.Migration {
+	return []*goose.Migration{
+		hashPlaintextPasswords(),
+	}
+}
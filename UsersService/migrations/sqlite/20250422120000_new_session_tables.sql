@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS Sessions(
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON Sessions(user_id);
+
+CREATE TABLE IF NOT EXISTS RefreshTokens(
+    token_hash TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL REFERENCES Sessions(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    used_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON RefreshTokens(session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS RefreshTokens;
+DROP TABLE IF EXISTS Sessions;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS Roles(
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS Permissions(
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS Role_Permissions(
+    role_id TEXT NOT NULL REFERENCES Roles(id) ON DELETE CASCADE,
+    permission_id TEXT NOT NULL REFERENCES Permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS User_Roles(
+    user_id TEXT NOT NULL REFERENCES Users(id) ON DELETE CASCADE,
+    role_id TEXT NOT NULL REFERENCES Roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+-- SQLite has no gen_random_uuid(), the seeded ids are fixed.
+INSERT INTO Roles(id, name, description)
+VALUES ('6a0b7c8e-1f2d-4e3a-9b5c-0d1e2f3a4b5c', 'admin', 'Full access to users, sessions and roles');
+
+INSERT INTO Permissions(id, name)
+VALUES
+    ('0f7c2b1e-8d4a-4c6e-9a1b-2c3d4e5f6a01', 'users:read'),
+    ('0f7c2b1e-8d4a-4c6e-9a1b-2c3d4e5f6a02', 'users:write'),
+    ('0f7c2b1e-8d4a-4c6e-9a1b-2c3d4e5f6a03', 'users:delete'),
+    ('0f7c2b1e-8d4a-4c6e-9a1b-2c3d4e5f6a04', 'sessions:manage'),
+    ('0f7c2b1e-8d4a-4c6e-9a1b-2c3d4e5f6a05', 'roles:manage');
+
+INSERT INTO Role_Permissions(role_id, permission_id)
+SELECT r.id, p.id FROM Roles r CROSS JOIN Permissions p
+WHERE r.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS User_Roles;
+DROP TABLE IF EXISTS Role_Permissions;
+DROP TABLE IF EXISTS Permissions;
+DROP TABLE IF EXISTS Roles;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ADD COLUMN can't default to CURRENT_TIMESTAMP, users created before get the epoch.
+ALTER TABLE Users ADD COLUMN created_at DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00+00:00';
+
+CREATE INDEX IF NOT EXISTS users_created_at_id_idx ON Users(created_at, id);
+CREATE INDEX IF NOT EXISTS users_login_id_idx ON Users(login, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_login_id_idx;
+DROP INDEX IF EXISTS users_created_at_id_idx;
+ALTER TABLE Users DROP COLUMN created_at;
+-- +goose StatementEnd
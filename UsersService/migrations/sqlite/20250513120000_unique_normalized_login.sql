@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite has no Unicode normalization and lower() only folds ASCII, the rest
+-- of models.NormalizeLogin is applied by UsersService before logins are stored.
+-- trim() is given the White_Space characters strings.TrimSpace trims, it
+-- only trims spaces otherwise.
+UPDATE Users SET login = lower(trim(login, ' ' || char(
+	9, 10, 11, 12, 13, 133, 160, 5760, 8192, 8193, 8194, 8195, 8196, 8197, 8198,
+	8199, 8200, 8201, 8202, 8232, 8233, 8239, 8287, 12288
+)));
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_login_normalized_idx ON Users(lower(trim(login, ' ' || char(
+	9, 10, 11, 12, 13, 133, 160, 5760, 8192, 8193, 8194, 8195, 8196, 8197, 8198,
+	8199, 8200, 8201, 8202, 8232, 8233, 8239, 8287, 12288
+))));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_login_normalized_idx;
+-- +goose StatementEnd
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"time"
@@ -13,11 +14,114 @@ type Config struct {
 	Grpc           GrpcConfig    `yaml:"grpc"`
 	ExpirationTime time.Duration `yaml:"expiration_time"`
 	ConnStr        string        `yaml:"conn_str"`
+	Storage        StorageConfig `yaml:"storage"`
+	Hasher         HasherConfig  `yaml:"hasher"`
+	Tokens         TokensConfig  `yaml:"tokens"`
+	Tracing        TracingConfig `yaml:"tracing"`
+}
+
+type TracingConfig struct {
+	// Exporter is one of "none", "stdout" or "otlp".
+	Exporter string `yaml:"exporter" env-default:"none"`
+	// Endpoint is the address of the OTLP gRPC collector.
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure" env-default:"true"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"users-service"`
+}
+
+const (
+	// DriverPostgres is database/sql with lib/pq.
+	DriverPostgres = "postgres"
+	// DriverPgx is a native pgx pool with prepared statements.
+	DriverPgx = "pgx"
+	// DriverMemory keeps the data in memory, no database is needed.
+	DriverMemory = "memory"
+	// DriverSQLite keeps the data in a SQLite file.
+	DriverSQLite = "sqlite"
+)
+
+type StorageConfig struct {
+	// Driver is DriverPostgres, DriverPgx, DriverMemory or DriverSQLite.
+	Driver string       `yaml:"driver" env-default:"postgres"`
+	Memory MemoryConfig `yaml:"memory"`
+	SQLite SQLiteConfig `yaml:"sqlite"`
+	// AutoMigrate applies pending migrations on start up. Turn it off when
+	// migrations run as a separate job, see "cli migrate".
+	AutoMigrate bool `yaml:"auto_migrate" env-default:"true"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"25"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"25"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+
+	// ConnectTimeout is how long start up waits for the database to answer
+	// pings, retrying with exponential backoff from InitialBackoff up to
+	// MaxBackoff.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env-default:"30s"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"200ms"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"5s"`
+}
+
+type MemoryConfig struct {
+	// SnapshotPath is the JSON file the data is loaded from on start up and
+	// saved to on shutdown. Empty keeps the data in memory only.
+	SnapshotPath string `yaml:"snapshot_path"`
+	// SnapshotInterval saves the data periodically as well, 0 disables it.
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+}
+
+type SQLiteConfig struct {
+	// Path is the database file, created if it doesn't exist.
+	Path string `yaml:"path" env-default:"users.db"`
 }
 
 type GrpcConfig struct {
 	Port    int           `yaml:"port"`
 	Timeout time.Duration `yaml:"timeout"`
+	// AdminPort serves /metrics over HTTP, apart from the gRPC port.
+	AdminPort int `yaml:"admin_port" env-default:"9090"`
+	// HealthCheckInterval is how often the database is pinged to update
+	// the grpc.health.v1.Health status.
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" env-default:"5s"`
+	// Reflection enables the server reflection service, for grpcurl and
+	// the like. Keep it off in production.
+	Reflection bool `yaml:"reflection"`
+}
+
+type HasherConfig struct {
+	Algorithm  string       `yaml:"algorithm" env-default:"argon2id"`
+	BcryptCost int          `yaml:"bcrypt_cost" env-default:"10"`
+	Argon2     Argon2Config `yaml:"argon2"`
+}
+
+// Argon2Config holds argon2id parameters, memory is in KiB.
+type Argon2Config struct {
+	Time       uint32 `yaml:"time" env-default:"1"`
+	Memory     uint32 `yaml:"memory" env-default:"65536"`
+	Threads    uint8  `yaml:"threads" env-default:"4"`
+	KeyLength  uint32 `yaml:"key_length" env-default:"32"`
+	SaltLength uint32 `yaml:"salt_length" env-default:"16"`
+}
+
+// TokensConfig describes access token signing keys. Tokens are signed with
+// SigningKey; the other keys are only published in the JWKS, which allows
+// rotating keys without invalidating tokens that were already issued.
+type TokensConfig struct {
+	Issuer     string      `yaml:"issuer" env-default:"users-service"`
+	SigningKey string      `yaml:"signing_key"`
+	Keys       []KeyConfig `yaml:"keys"`
+	// RefreshTTL is how long a session lasts without being refreshed, every
+	// refresh extends it, up to SessionMaxLifetime after its creation.
+	RefreshTTL         time.Duration `yaml:"refresh_ttl" env-default:"720h"`
+	SessionMaxLifetime time.Duration `yaml:"session_max_lifetime" env-default:"2160h"`
+}
+
+// KeyConfig points to a PEM encoded Ed25519 or RSA private key.
+type KeyConfig struct {
+	Id        string `yaml:"id"`
+	Algorithm string `yaml:"algorithm"`
+	Path      string `yaml:"path"`
 }
 
 func MustLoad() *Config {
@@ -41,9 +145,28 @@ func MustLoadPath(configPath string) *Config {
 		panic("cannot read config: " + err.Error())
 	}
 
+	if err := cfg.Storage.validate(); err != nil {
+		panic("invalid storage config: " + err.Error())
+	}
+
 	return &cfg
 }
 
+// validate rejects connection backoffs that would retry without a delay,
+// or never.
+func (c StorageConfig) validate() error {
+	switch {
+	case c.ConnectTimeout <= 0:
+		return errors.New("connect_timeout must be positive")
+	case c.InitialBackoff <= 0:
+		return errors.New("initial_backoff must be positive")
+	case c.MaxBackoff < c.InitialBackoff:
+		return errors.New("max_backoff must be at least initial_backoff")
+	}
+
+	return nil
+}
+
 // fetchConfigPath fetches config path from command line flag or environment variable.
 // Priority: flag > env > default.
 // Default value is empty string.
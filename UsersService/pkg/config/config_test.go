@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorageConfigValidate(t *testing.T) {
+	valid := StorageConfig{
+		ConnectTimeout: 30 * time.Second,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*StorageConfig)
+		valid  bool
+	}{
+		{"defaults", func(*StorageConfig) {}, true},
+		{"constant backoff", func(c *StorageConfig) { c.MaxBackoff = c.InitialBackoff }, true},
+		{"zero connect timeout", func(c *StorageConfig) { c.ConnectTimeout = 0 }, false},
+		{"zero initial backoff", func(c *StorageConfig) { c.InitialBackoff = 0 }, false},
+		{"negative initial backoff", func(c *StorageConfig) { c.InitialBackoff = -time.Second }, false},
+		{"max backoff below initial", func(c *StorageConfig) { c.MaxBackoff = 100 * time.Millisecond }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.mutate(&cfg)
+
+			if err := cfg.validate(); (err == nil) != tt.valid {
+				t.Errorf("validate(%+v) = %v, want valid = %v", cfg, err, tt.valid)
+			}
+		})
+	}
+}
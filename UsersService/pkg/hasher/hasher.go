@@ -0,0 +1,192 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"users-service/pkg/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// Bounds of the argon2id parameters, checked both in the config and in
+// stored hashes: a corrupt row must neither panic argon2.IDKey nor make it
+// allocate gigabytes on every login attempt.
+const (
+	argon2MaxTime      = 16
+	argon2MaxMemory    = 1 << 20 // KiB, 1 GiB
+	argon2MaxThreads   = 64
+	argon2MinKeyLength = 16
+	argon2MaxKeyLength = 64
+	argon2MinSaltLen   = 8
+	argon2MaxSaltLen   = 64
+)
+
+var (
+	ErrMismatchedHash   = errors.New("password doesn't match hash")
+	ErrInvalidHash      = errors.New("invalid encoded hash")
+	ErrUnknownAlgorithm = errors.New("unknown hashing algorithm")
+)
+
+// PasswordHasher hashes passwords with the configured algorithm and verifies
+// hashes produced by any supported algorithm, so switching the algorithm
+// doesn't invalidate passwords that are already stored.
+type PasswordHasher struct {
+	algorithm  string
+	argon2     config.Argon2Config
+	bcryptCost int
+}
+
+func New(cfg config.HasherConfig) (*PasswordHasher, error) {
+	const op = "hasher.New"
+
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id:
+		if err := checkArgon2(cfg.Argon2); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	case AlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("%s: bcrypt cost must be in [%d, %d]", op, bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: %q", op, ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+
+	return &PasswordHasher{
+		algorithm:  cfg.Algorithm,
+		argon2:     cfg.Argon2,
+		bcryptCost: cfg.BcryptCost,
+	}, nil
+}
+
+// Hash returns the encoded hash of password. Argon2id hashes are encoded in
+// the PHC string format, bcrypt hashes in the usual modular crypt format.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	const op = "hasher.Hash"
+
+	switch h.algorithm {
+	case AlgorithmBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		return string(hash), nil
+	default:
+		salt := make([]byte, h.argon2.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		key := argon2.IDKey([]byte(password), salt, h.argon2.Time, h.argon2.Memory, h.argon2.Threads, h.argon2.KeyLength)
+
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, h.argon2.Memory, h.argon2.Time, h.argon2.Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	}
+}
+
+// Compare checks password against an encoded hash in constant time.
+// It returns ErrMismatchedHash if the password is wrong.
+func (h *PasswordHasher) Compare(hash, password string) error {
+	const op = "hasher.Compare"
+
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return compareArgon2id(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedHash
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w: %w", op, ErrInvalidHash, err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%s: %w", op, ErrInvalidHash)
+	}
+}
+
+func compareArgon2id(hash, password string) error {
+	const op = "hasher.compareArgon2id"
+
+	// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return fmt.Errorf("%s: %w", op, ErrInvalidHash)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return fmt.Errorf("%s: %w: unsupported version", op, ErrInvalidHash)
+	}
+
+	var (
+		memory, time uint32
+		threads      uint8
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidHash, err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidHash, err)
+	}
+
+	err = checkArgon2(config.Argon2Config{
+		Time:       time,
+		Memory:     memory,
+		Threads:    threads,
+		KeyLength:  uint32(len(key)),
+		SaltLength: uint32(len(salt)),
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w: %w", op, ErrInvalidHash, err)
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedHash
+	}
+
+	return nil
+}
+
+// checkArgon2 returns an error if params are out of the bounds above.
+// argon2 also needs at least 8 KiB of memory per thread.
+func checkArgon2(params config.Argon2Config) error {
+	switch {
+	case params.Time < 1 || params.Time > argon2MaxTime:
+		return fmt.Errorf("argon2 time must be in [1, %d]", argon2MaxTime)
+	case params.Threads < 1 || params.Threads > argon2MaxThreads:
+		return fmt.Errorf("argon2 threads must be in [1, %d]", argon2MaxThreads)
+	case params.Memory < 8*uint32(params.Threads) || params.Memory > argon2MaxMemory:
+		return fmt.Errorf("argon2 memory must be in [8*threads, %d] KiB", argon2MaxMemory)
+	case params.KeyLength < argon2MinKeyLength || params.KeyLength > argon2MaxKeyLength:
+		return fmt.Errorf("argon2 key length must be in [%d, %d]", argon2MinKeyLength, argon2MaxKeyLength)
+	case params.SaltLength < argon2MinSaltLen || params.SaltLength > argon2MaxSaltLen:
+		return fmt.Errorf("argon2 salt length must be in [%d, %d]", argon2MinSaltLen, argon2MaxSaltLen)
+	}
+
+	return nil
+}
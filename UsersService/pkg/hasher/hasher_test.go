@@ -0,0 +1,96 @@
+package hasher_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"users-service/pkg/config"
+	"users-service/pkg/hasher"
+)
+
+var argon2Config = config.Argon2Config{
+	Time:       1,
+	Memory:     64,
+	Threads:    1,
+	KeyLength:  32,
+	SaltLength: 16,
+}
+
+func TestNewRejectsArgon2Params(t *testing.T) {
+	for name, mutate := range map[string]func(*config.Argon2Config){
+		"zero time":        func(c *config.Argon2Config) { c.Time = 0 },
+		"zero threads":     func(c *config.Argon2Config) { c.Threads = 0 },
+		"zero key length":  func(c *config.Argon2Config) { c.KeyLength = 0 },
+		"zero salt length": func(c *config.Argon2Config) { c.SaltLength = 0 },
+		"too much memory":  func(c *config.Argon2Config) { c.Memory = 1 << 30 },
+		"too little memory": func(c *config.Argon2Config) {
+			c.Threads = 4
+			c.Memory = 16
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := argon2Config
+			mutate(&cfg)
+
+			_, err := hasher.New(config.HasherConfig{Algorithm: hasher.AlgorithmArgon2id, Argon2: cfg})
+			if err == nil {
+				t.Fatalf("New(%+v) succeeded", cfg)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, cfg := range []config.HasherConfig{
+		{Algorithm: hasher.AlgorithmArgon2id, Argon2: argon2Config},
+		{Algorithm: hasher.AlgorithmBcrypt, BcryptCost: 4},
+	} {
+		t.Run(cfg.Algorithm, func(t *testing.T) {
+			h, err := hasher.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hash, err := h.Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := h.Compare(hash, "correct horse"); err != nil {
+				t.Errorf("Compare(right password) = %v", err)
+			}
+			if err := h.Compare(hash, "wrong horse"); !errors.Is(err, hasher.ErrMismatchedHash) {
+				t.Errorf("Compare(wrong password) = %v, want ErrMismatchedHash", err)
+			}
+		})
+	}
+}
+
+func TestCompareRejectsCorruptHashes(t *testing.T) {
+	h, err := hasher.New(config.HasherConfig{Algorithm: hasher.AlgorithmArgon2id, Argon2: argon2Config})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+
+	for name, corrupt := range map[string]string{
+		"zero threads":  strings.Join([]string{"", "argon2id", "v=19", "m=64,t=1,p=0", parts[4], parts[5]}, "$"),
+		"zero time":     strings.Join([]string{"", "argon2id", "v=19", "m=64,t=0,p=1", parts[4], parts[5]}, "$"),
+		"huge memory":   strings.Join([]string{"", "argon2id", "v=19", "m=4294967295,t=1,p=1", parts[4], parts[5]}, "$"),
+		"empty key":     strings.Join([]string{"", "argon2id", "v=19", "m=64,t=1,p=1", parts[4], ""}, "$"),
+		"empty salt":    strings.Join([]string{"", "argon2id", "v=19", "m=64,t=1,p=1", "", parts[5]}, "$"),
+		"plaintext":     "correct horse",
+		"missing parts": "$argon2id$v=19$m=64,t=1,p=1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := h.Compare(corrupt, "correct horse"); !errors.Is(err, hasher.ErrInvalidHash) {
+				t.Errorf("Compare(%q) = %v, want ErrInvalidHash", corrupt, err)
+			}
+		})
+	}
+}
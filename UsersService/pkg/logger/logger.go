@@ -15,13 +15,13 @@ func SetupLogger(env string) *slog.Logger {
 	case constants.EnvLocal:
 		log = setupPrettySlog()
 	case constants.EnvDev:
-		log = slog.New(
+		log = slog.New(NewContextHandler(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
+		))
 	case constants.EnvProd:
-		log = slog.New(
+		log = slog.New(NewContextHandler(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		))
 	}
 
 	return log
@@ -36,5 +36,5 @@ func setupPrettySlog() *slog.Logger {
 
 	handler := opts.NewPrettyHandler(os.Stdout)
 
-	return slog.New(handler)
+	return slog.New(NewContextHandler(handler))
 }
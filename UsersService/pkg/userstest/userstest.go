@@ -0,0 +1,85 @@
+// Package userstest runs UsersService in process on the memory storage, for
+// end to end tests of its clients. It is wired like cmd/app, but with cheap
+// password hashing and an ephemeral signing key.
+package userstest
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+	"users-service/internal/app"
+	"users-service/internal/storage/memstorage"
+	"users-service/internal/tokens"
+	"users-service/pkg/config"
+	"users-service/pkg/hasher"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Issuer is the "iss" claim of the issued tokens.
+	Issuer = "users-service"
+
+	accessTTL          = 15 * time.Minute
+	refreshTTL         = 24 * time.Hour
+	sessionMaxLifetime = 7 * 24 * time.Hour
+)
+
+// Server is UsersService with empty storages, apart from the seeded roles.
+type Server struct {
+	app     *app.App
+	storage *memstorage.MemStorage
+}
+
+// New builds the server, Serve starts it.
+func New(log *slog.Logger) (*Server, error) {
+	const op = "userstest.New"
+
+	storage, err := memstorage.New(log, config.MemoryConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passwordHasher, err := hasher.New(config.HasherConfig{
+		Algorithm:  hasher.AlgorithmBcrypt,
+		BcryptCost: bcrypt.MinCost,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	issuer, err := tokens.NewEphemeral(Issuer, accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	application := app.New(
+		log,
+		storage,
+		storage,
+		storage,
+		storage,
+		passwordHasher,
+		issuer,
+		refreshTTL,
+		sessionMaxLifetime,
+		config.GrpcConfig{HealthCheckInterval: time.Second},
+	)
+
+	return &Server{
+		app:     application,
+		storage: storage,
+	}, nil
+}
+
+// Serve serves gRPC calls on l until Stop is called.
+func (s *Server) Serve(l net.Listener) error {
+	return s.app.GRPCServer.Serve(l)
+}
+
+// Stop waits for the in-flight calls and closes the storage.
+func (s *Server) Stop() {
+	s.app.GRPCServer.Stop()
+	s.storage.Close()
+}
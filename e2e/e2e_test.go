@@ -0,0 +1,123 @@
+package e2e_test
+
+import (
+	"e2e"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUserLifecycle(t *testing.T) {
+	h := e2e.New(t)
+	id := uuid.NewString()
+
+	h.ExpectGolden("create_user", h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       id,
+		"login":    "alice",
+		"password": "correct horse battery staple",
+	}))
+
+	h.ExpectGolden("login", h.Login("alice", "correct horse battery staple"))
+	h.ExpectGolden("get_user", h.Do(http.MethodGet, "/api/v1/users/"+id, nil))
+
+	h.ExpectGolden("update_user", h.Do(http.MethodPut, "/api/v1/users/"+id, map[string]string{
+		"login":    "alice2",
+		"password": "another horse battery staple",
+	}))
+	h.ExpectGolden("get_updated_user", h.Do(http.MethodGet, "/api/v1/users/"+id, nil))
+	h.ExpectGolden("login_updated", h.Login("alice2", "another horse battery staple"))
+
+	h.ExpectGolden("delete_user", h.Do(http.MethodDelete, "/api/v1/users/"+id, nil))
+	h.ExpectGolden("get_deleted_user", h.Do(http.MethodGet, "/api/v1/users/"+id, nil))
+}
+
+func TestUnauthenticated(t *testing.T) {
+	h := e2e.New(t)
+
+	h.ExpectGolden("list_users_unauthenticated", h.Do(http.MethodGet, "/api/v1/users", nil))
+	h.ExpectGolden("login_invalid", h.Do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"login":    "nobody",
+		"password": "whatever",
+	}))
+}
+
+func TestLoginTaken(t *testing.T) {
+	h := e2e.New(t)
+	aliceId, bobId := uuid.NewString(), uuid.NewString()
+
+	// Logins are stored normalized.
+	h.ExpectGolden("create_user_normalized", h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       aliceId,
+		"login":    "  Ａlice ",
+		"password": "correct horse battery staple",
+	}))
+	h.ExpectGolden("create_user_login_taken", h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       bobId,
+		"login":    "ALICE",
+		"password": "correct horse battery staple",
+	}))
+
+	h.ExpectStatus(h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       bobId,
+		"login":    "bob",
+		"password": "correct horse battery staple",
+	}), http.StatusCreated)
+	h.Login("Bob", "correct horse battery staple")
+
+	h.ExpectGolden("update_user_login_taken", h.Do(http.MethodPut, "/api/v1/users/"+bobId, map[string]string{
+		"login":    "alice",
+		"password": "correct horse battery staple",
+	}))
+}
+
+func TestInvalidInput(t *testing.T) {
+	h := e2e.New(t)
+	id := uuid.NewString()
+
+	// Every invalid field is reported, not just the first one.
+	h.ExpectGolden("create_user_invalid", h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"login":    "-a",
+		"password": "short",
+	}))
+	h.ExpectGolden("create_user_password_too_long", h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       id,
+		"login":    "carol",
+		"password": strings.Repeat("x", 10<<10),
+	}))
+
+	h.ExpectStatus(h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+		"id":       id,
+		"login":    "carol",
+		"password": "correct horse battery staple",
+	}), http.StatusCreated)
+	h.Login("carol", "correct horse battery staple")
+
+	h.ExpectGolden("update_user_invalid_login", h.Do(http.MethodPut, "/api/v1/users/"+id, map[string]string{
+		"login":    "carol smith",
+		"password": "correct horse battery staple",
+	}))
+	h.ExpectGolden("get_user_nil_id", h.Do(http.MethodGet, "/api/v1/users/"+uuid.Nil.String(), nil))
+	h.ExpectGolden("list_users_invalid_page_token", h.Do(http.MethodGet, "/api/v1/users?page_token=garbage", nil))
+	h.ExpectGolden("list_users_invalid_time_range", h.Do(http.MethodGet,
+		"/api/v1/users?created_after=2025-05-02T00:00:00Z&created_before=2025-05-01T00:00:00Z", nil))
+	h.ExpectGolden("list_users_invalid_params", h.Do(http.MethodGet,
+		"/api/v1/users?page_size=-1&created_before=yesterday&sort=name", nil))
+}
+
+func TestListUsersByLoginPrefix(t *testing.T) {
+	h := e2e.New(t)
+
+	for _, login := range []string{"Alice", "alfred", "bob"} {
+		h.ExpectStatus(h.Do(http.MethodPost, "/api/v1/users", map[string]string{
+			"id":       uuid.NewString(),
+			"login":    login,
+			"password": "correct horse battery staple",
+		}), http.StatusCreated)
+	}
+	h.Login("bob", "correct horse battery staple")
+
+	// The prefix is normalized like the logins it is matched with.
+	h.ExpectGolden("list_users_login_prefix", h.Do(http.MethodGet, "/api/v1/users?login_prefix=Al", nil))
+}
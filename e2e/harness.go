@@ -0,0 +1,214 @@
+// Package e2e runs the API against UsersService in a single process: the
+// gRPC server listens on a bufconn listener, the API reaches it through a
+// custom dialer and its router is served by an httptest.Server. Nothing but
+// the test binary is needed, no database, no docker-compose.
+package e2e
+
+import (
+	"api/pkg/apitest"
+	"api/pkg/config"
+	"bytes"
+	"context"
+	_ "e2e/internal/protoconflict"
+	"encoding/json"
+	"flag"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"users-service/pkg/userstest"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the e2e tests")
+
+const bufSize = 1 << 20
+
+// Harness is one API and UsersService pair with empty storages.
+type Harness struct {
+	t      *testing.T
+	Server *httptest.Server
+	token  string
+	ids    map[string]string
+}
+
+// New starts the services, they are stopped when the test ends.
+func New(t *testing.T) *Harness {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	listener := bufconn.Listen(bufSize)
+
+	users, err := userstest.New(log)
+	if err != nil {
+		t.Fatalf("cannot create UsersService: %v", err)
+	}
+	go users.Serve(listener)
+	t.Cleanup(users.Stop)
+
+	var cfg config.Config
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		t.Fatalf("cannot read API config: %v", err)
+	}
+	cfg.UsersService.Addresses = []string{"bufconn"}
+	cfg.Auth.Issuer = userstest.Issuer
+	// Nothing balances load in front of the test server.
+	cfg.Api.Readiness.DrainDelay = 0
+
+	api := apitest.New(log, &cfg, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	t.Cleanup(func() {
+		if err := api.Close(); err != nil {
+			t.Errorf("cannot close API: %v", err)
+		}
+	})
+
+	server := httptest.NewServer(api.Handler())
+	t.Cleanup(server.Close)
+
+	return &Harness{
+		t:      t,
+		Server: server,
+		ids:    make(map[string]string),
+	}
+}
+
+// Response is a response with its body read.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Decode unmarshals the JSON body into v.
+func (r *Response) Decode(t *testing.T, v any) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("cannot decode %q: %v", r.Body, err)
+	}
+}
+
+// Do sends a request with body encoded as JSON, unless it's nil. It carries
+// the access token of the last Login.
+func (h *Harness) Do(method, path string, body any) *Response {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("cannot encode body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, h.Server.URL+path, reader)
+	if err != nil {
+		h.t.Fatalf("cannot create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	res, err := h.Server.Client().Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	read, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatalf("%s %s: cannot read body: %v", method, path, err)
+	}
+
+	return &Response{
+		Status: res.StatusCode,
+		Header: res.Header,
+		Body:   read,
+	}
+}
+
+// Login logs in and keeps the access token for the next requests.
+func (h *Harness) Login(login, password string) *Response {
+	h.t.Helper()
+
+	h.token = ""
+	res := h.Do(http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"login":    login,
+		"password": password,
+	})
+	h.ExpectStatus(res, http.StatusOK)
+
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+	}
+	res.Decode(h.t, &tokens)
+	h.token = tokens.AccessToken
+
+	return res
+}
+
+// ExpectStatus fails the test unless res has the status.
+func (h *Harness) ExpectStatus(res *Response, status int) {
+	h.t.Helper()
+
+	if res.Status != status {
+		h.t.Fatalf("got status %d, want %d, body %q", res.Status, status, res.Body)
+	}
+}
+
+// ExpectGolden compares the status and body of res to
+// testdata/<name>.golden.json, rewritten instead with -update. Values
+// differing from run to run are replaced by placeholders, see normalize.
+func (h *Harness) ExpectGolden(name string, res *Response) {
+	h.t.Helper()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]any{
+		"status": res.Status,
+		"body":   h.normalize(decodeBody(res.Body)),
+	}); err != nil {
+		h.t.Fatalf("cannot encode %s: %v", name, err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			h.t.Fatalf("cannot write %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("cannot read %s, run with -update to create it: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		h.t.Errorf("%s doesn't match %s\ngot:\n%s\nwant:\n%s", name, path, got, want)
+	}
+}
+
+// decodeBody decodes a JSON body, bodies of http.Error are kept as text.
+func decodeBody(body []byte) any {
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return string(body)
+	}
+	return decoded
+}
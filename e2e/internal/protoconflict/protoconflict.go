@@ -0,0 +1,14 @@
+// Package protoconflict lets the API and UsersService share a binary.
+//
+// Both register their own copy of users.proto, which panics by default.
+// The copies are the same file, so the second registration is ignored: each
+// side still marshals with its own generated types. Go initializes packages
+// in import path order, so this one runs before users-service/proto/gen and
+// after nothing that matters; it must stay free of other imports.
+package protoconflict
+
+import "os"
+
+func init() {
+	os.Setenv("GOLANG_PROTOBUF_REGISTRATION_CONFLICT", "ignore")
+}
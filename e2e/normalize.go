@@ -0,0 +1,56 @@
+package e2e
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// volatile maps the keys whose values change from run to run to their
+// placeholders.
+var volatile = map[string]string{
+	"password":      "<hash>",
+	"created_at":    "<time>",
+	"expires_at":    "<time>",
+	"last_used_at":  "<time>",
+	"access_token":  "<token>",
+	"refresh_token": "<token>",
+}
+
+// normalize replaces the volatile values of a decoded body. Ids become
+// "<id-N>", numbered in order of appearance for the whole harness, so a
+// golden file still shows that two responses are about the same user.
+func (h *Harness) normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		// Sorted, so ids are numbered the same way on every run.
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			value := v[key]
+			if placeholder, ok := volatile[key]; ok && value != nil {
+				v[key] = placeholder
+				continue
+			}
+			v[key] = h.normalize(value)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = h.normalize(value)
+		}
+		return v
+	case string:
+		if _, err := uuid.Parse(v); err != nil {
+			return v
+		}
+		if placeholder, ok := h.ids[v]; ok {
+			return placeholder
+		}
+		placeholder := fmt.Sprintf("<id-%d>", len(h.ids)+1)
+		h.ids[v] = placeholder
+		return placeholder
+	default:
+		return v
+	}
+}